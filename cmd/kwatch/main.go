@@ -20,10 +20,22 @@ func main() {
 		}
 	}
 
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if len(stateDir) <= 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			stateDir = confDir
+		} else {
+			stateDir = filepath.Join(homeDir, ".local", "state")
+		}
+	}
+
 	confFile := flag.String("c", confDir+"/kwatch.toml", "Configuration file [optional]")
+	stateFile := flag.String("s", stateDir+"/kwatch/state.json", "Playback state file [optional]")
 	flag.Parse()
 
 	confFilePath := filepath.Clean(*confFile)
+	stateFilePath := filepath.Clean(*stateFile)
 
 	config := new(cfg.Config)
 	err = config.ReadConfig(confFilePath)
@@ -33,7 +45,15 @@ func main() {
 		}
 	}
 
-	program := ui.NewProgram(config, confFilePath)
+	state := new(cfg.State)
+	err = state.ReadState(stateFilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+	}
+
+	program := ui.NewProgram(config, confFilePath, state, stateFilePath)
 
 	if err := program.Start(); err != nil {
 		log.Fatal(err)
@@ -4,43 +4,141 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ibrokemypie/kwatch/pkg/player"
 	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
 	"github.com/pelletier/go-toml/v2"
 )
 
+// defaultWatchedPercent is how much of a file has to be played for it to
+// count as watched when WatchedPercent is not set.
+const defaultWatchedPercent = 90
+
 type Config struct {
-	Bookmarks       []bookmark.Bookmark
-	DefaultBookmark int
+	Bookmarks []bookmark.Bookmark
+	// DefaultBookmarkID is the ID of the bookmark opened on start, or empty
+	// to start in the bookmark picker.
+	DefaultBookmarkID string
+	Players           []player.Profile
+	WatchedPercent    int
 }
 
 func (cfg Config) GetBookmarks() []bookmark.Bookmark {
 	return cfg.Bookmarks
 }
 
+// GetDefaultBookmark returns the index of the default bookmark, or -1 if
+// there is none.
 func (cfg Config) GetDefaultBookmark() int {
-	if len(cfg.Bookmarks) > 0 {
-		return cfg.DefaultBookmark
-	} else {
+	if len(cfg.DefaultBookmarkID) <= 0 {
 		return -1
 	}
+
+	return cfg.FindBookmarkID(cfg.DefaultBookmarkID)
+}
+
+// SetDefaultBookmark makes the bookmark with id the default, an empty id
+// clears it.
+func (cfg *Config) SetDefaultBookmark(id string) {
+	cfg.DefaultBookmarkID = id
 }
 
-func (cfg *Config) SetDefaultBookmark(newDefaultBookmark int) {
-	cfg.DefaultBookmark = newDefaultBookmark
+// FindBookmarkID returns the index of the bookmark with id, or -1.
+func (cfg Config) FindBookmarkID(id string) int {
+	for i, b := range cfg.Bookmarks {
+		if b.ID == id {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func (cfg Config) GetBookmark(index int) bookmark.Bookmark {
 	return cfg.Bookmarks[index]
 }
 
+// FindBookmark returns the first bookmark for address, ignoring credentials.
+func (cfg Config) FindBookmark(address string) (bookmark.Bookmark, bool) {
+	for _, b := range cfg.Bookmarks {
+		if RedactAddress(b.Address) == RedactAddress(address) {
+			return b, true
+		}
+	}
+
+	return bookmark.Bookmark{}, false
+}
+
 func (cfg *Config) AddBookmark(b bookmark.Bookmark) {
+	if len(b.ID) <= 0 {
+		b.ID = bookmark.NewID()
+	}
+
 	cfg.Bookmarks = append(cfg.Bookmarks, b)
 }
 
+// UpdateBookmark replaces a bookmark, keeping its ID.
 func (cfg *Config) UpdateBookmark(index int, b bookmark.Bookmark) {
+	b.ID = cfg.Bookmarks[index].ID
 	cfg.Bookmarks[index] = b
 }
 
+// DeleteBookmark removes a bookmark, clearing the default if it was the
+// default.
+func (cfg *Config) DeleteBookmark(index int) {
+	if cfg.Bookmarks[index].ID == cfg.DefaultBookmarkID {
+		cfg.DefaultBookmarkID = ""
+	}
+
+	cfg.Bookmarks = append(cfg.Bookmarks[:index], cfg.Bookmarks[index+1:]...)
+}
+
+// DuplicateBookmark inserts a copy of a bookmark with a new ID after it.
+func (cfg *Config) DuplicateBookmark(index int) {
+	duplicate := cfg.Bookmarks[index]
+	duplicate.ID = bookmark.NewID()
+
+	cfg.Bookmarks = append(cfg.Bookmarks[:index+1], cfg.Bookmarks[index:]...)
+	cfg.Bookmarks[index+1] = duplicate
+}
+
+// SwapBookmarks exchanges the places of two bookmarks.
+func (cfg *Config) SwapBookmarks(i, j int) {
+	cfg.Bookmarks[i], cfg.Bookmarks[j] = cfg.Bookmarks[j], cfg.Bookmarks[i]
+}
+
+// GetWatchedThreshold is the fraction of a file that has to be played for it
+// to be marked watched.
+func (cfg Config) GetWatchedThreshold() float64 {
+	if cfg.WatchedPercent <= 0 || cfg.WatchedPercent > 100 {
+		return defaultWatchedPercent / 100.0
+	}
+
+	return float64(cfg.WatchedPercent) / 100
+}
+
+// GetPlayer looks a bookmark's player up by name in the configured profiles,
+// then the built in ones, and otherwise runs it as a command. An empty name
+// is the default player.
+func (cfg Config) GetPlayer(name string) player.Player {
+	if len(name) <= 0 {
+		name = player.DefaultProfile
+	}
+
+	for _, profile := range cfg.Players {
+		if profile.Name == name {
+			return profile
+		}
+	}
+
+	for _, profile := range player.BuiltinProfiles {
+		if profile.Name == name {
+			return profile
+		}
+	}
+
+	return player.CommandProfile(name)
+}
+
 func (cfg Config) WriteConfig(confFilePath string) error {
 	bytes, err := toml.Marshal(cfg)
 	if err != nil {
@@ -77,5 +175,32 @@ func (cfg *Config) ReadConfig(confFilePath string) error {
 		return err
 	}
 
+	return cfg.migrate(bytes)
+}
+
+// migrate gives bookmarks from older configs IDs, and moves the default from
+// the old DefaultBookmark index to its bookmark's ID.
+func (cfg *Config) migrate(bytes []byte) error {
+	for i := range cfg.Bookmarks {
+		b := &cfg.Bookmarks[i]
+
+		if len(b.ID) <= 0 {
+			b.ID = bookmark.NewID()
+		}
+	}
+
+	var legacy map[string]interface{}
+	err := toml.Unmarshal(bytes, &legacy)
+	if err != nil {
+		return err
+	}
+
+	// Old versions wrote DefaultBookmark = 0 whether or not a default was
+	// chosen, so only other indexes are taken as one.
+	index, ok := legacy["DefaultBookmark"].(int64)
+	if ok && len(cfg.DefaultBookmarkID) <= 0 && index > 0 && int(index) < len(cfg.Bookmarks) {
+		cfg.DefaultBookmarkID = cfg.Bookmarks[index].ID
+	}
+
 	return nil
 }
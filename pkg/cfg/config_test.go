@@ -0,0 +1,60 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigMigratesLegacyConfigs(t *testing.T) {
+	confFilePath := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(confFilePath, []byte(`DefaultBookmark = 1
+
+[[Bookmarks]]
+Address = "https://files.host.tld"
+
+[[Bookmarks]]
+Address = "https://nas.lan"
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if err := cfg.ReadConfig(confFilePath); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := cfg.Bookmarks[0], cfg.Bookmarks[1]
+	if len(first.ID) <= 0 || len(second.ID) <= 0 {
+		t.Fatal("bookmarks were not given IDs")
+	}
+	if cfg.DefaultBookmarkID != second.ID {
+		t.Errorf("default bookmark is %q, want %q", cfg.DefaultBookmarkID, second.ID)
+	}
+
+	if first.Address != "https://files.host.tld" {
+		t.Errorf("HTTP address became %s", first.Address)
+	}
+}
+
+func TestReadConfigIgnoresLegacyZeroDefault(t *testing.T) {
+	confFilePath := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(confFilePath, []byte(`DefaultBookmark = 0
+
+[[Bookmarks]]
+Address = "https://files.host.tld"
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if err := cfg.ReadConfig(confFilePath); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.DefaultBookmarkID) > 0 || cfg.GetDefaultBookmark() >= 0 {
+		t.Errorf("default bookmark is %q, want none", cfg.DefaultBookmarkID)
+	}
+}
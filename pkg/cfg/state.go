@@ -0,0 +1,114 @@
+package cfg
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// State is what kwatch remembers about played files. It is kept apart from
+// the config file, which is only written when the user changes something.
+type State struct {
+	Files map[string]FileState
+}
+
+// FileState is kept per file, Address, Dir and Name say where to find it
+// again.
+type FileState struct {
+	Address    string
+	Dir        string
+	Name       string
+	Position   time.Duration
+	Duration   time.Duration
+	LastPlayed time.Time
+	Watched    bool
+}
+
+// Progress is the fraction of the file played before it was stopped.
+func (f FileState) Progress() float64 {
+	if f.Duration <= 0 {
+		return 0
+	}
+
+	return float64(f.Position) / float64(f.Duration)
+}
+
+// NewFileState starts the state of a file, the address is stored without
+// credentials.
+func NewFileState(address, dir, name string) FileState {
+	return FileState{Address: RedactAddress(address), Dir: dir, Name: name}
+}
+
+// Key identifies a file across runs by its source's address and its path,
+// so changing a bookmark's login or moving it in the list does not lose its
+// state.
+func (f FileState) Key() string {
+	return f.Address + path.Join("/", f.Dir, f.Name)
+}
+
+// RedactAddress removes any credentials from address.
+func RedactAddress(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+
+	parsed.User = nil
+	return parsed.String()
+}
+
+func (s State) GetFile(key string) (FileState, bool) {
+	file, ok := s.Files[key]
+	return file, ok
+}
+
+func (s *State) UpdateFile(file FileState) {
+	if s.Files == nil {
+		s.Files = map[string]FileState{}
+	}
+
+	s.Files[file.Key()] = file
+}
+
+// History is the files that have been played, most recent first.
+func (s State) History() []FileState {
+	history := []FileState{}
+	for _, file := range s.Files {
+		if len(file.Name) > 0 && !file.LastPlayed.IsZero() {
+			history = append(history, file)
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].LastPlayed.After(history[j].LastPlayed)
+	})
+
+	return history
+}
+
+func (s State) WriteState(stateFilePath string) error {
+	bytes, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(stateFilePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(stateFilePath, bytes, 0644)
+}
+
+func (s *State) ReadState(stateFilePath string) error {
+	bytes, err := os.ReadFile(stateFilePath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, s)
+}
@@ -0,0 +1,116 @@
+package loopback
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server hands content to a player over HTTP on localhost, for sources the
+// player cannot read from directly. Paths are prefixed with a random token so
+// other local users cannot guess them.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+	token    string
+}
+
+func Serve(handler http.Handler) (*Server, error) {
+	tokenBytes := make([]byte, 16)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		token:    hex.EncodeToString(tokenBytes),
+	}
+	s.server = &http.Server{Handler: http.StripPrefix("/"+s.token, handler)}
+
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+// URL returns the address a player can request path from.
+func (s *Server) URL(path string) string {
+	address := url.URL{
+		Scheme: "http",
+		Host:   s.listener.Addr().String(),
+		Path:   "/" + s.token + "/" + strings.TrimPrefix(path, "/"),
+	}
+
+	return address.String()
+}
+
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// FileHandler serves a single file, opening it again for every request so
+// players can make concurrent range requests while seeking.
+func FileHandler(name string, modTime time.Time, open func() (io.ReadSeekCloser, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, err := open()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer content.Close()
+
+		http.ServeContent(w, r, name, modTime, content)
+	})
+}
+
+// StreamHandler serves content that cannot seek, like a compressed archive
+// member. Players can still play it, but not seek past what was sent.
+func StreamHandler(size int64, open func() (io.ReadCloser, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, err := open()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer content.Close()
+
+		w.Header().Set("Accept-Ranges", "none")
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		io.Copy(w, content)
+	})
+}
+
+// ProxyHandler forwards requests to target's host with basic auth added, so
+// players can be handed URLs without credentials in them. Failed requests
+// are not logged, stderr belongs to the UI.
+func ProxyHandler(target *url.URL, username, password string) http.Handler {
+	return &httputil.ReverseProxy{
+		ErrorLog: log.New(io.Discard, "", 0),
+		Director: func(r *http.Request) {
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			r.Host = target.Host
+			r.SetBasicAuth(username, password)
+		},
+	}
+}
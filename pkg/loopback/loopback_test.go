@@ -0,0 +1,70 @@
+package loopback
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxyAddsCredentials(t *testing.T) {
+	var gotPath, gotQuery, gotUser, gotPass string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotUser, gotPass, _ = r.BasicAuth()
+		io.WriteString(w, "video")
+	}))
+	defer upstream.Close()
+
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := Serve(ProxyHandler(target, "user", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	response, err := http.Get(server.URL("/shows/Episode 1.mkv") + "?part=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if string(body) != "video" {
+		t.Fatalf("proxied %q, want video", body)
+	}
+	if gotPath != "/shows/Episode 1.mkv" || gotQuery != "part=1" {
+		t.Fatalf("upstream got %q?%q, want /shows/Episode 1.mkv?part=1", gotPath, gotQuery)
+	}
+	if gotUser != "user" || gotPass != "secret" {
+		t.Fatalf("upstream got credentials %q:%q, want user:secret", gotUser, gotPass)
+	}
+}
+
+func TestServerNeedsToken(t *testing.T) {
+	server, err := Serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	address, err := url.Parse(server.URL("file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := http.Get("http://" + address.Host + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("request without the token got %s, want 404", response.Status)
+	}
+}
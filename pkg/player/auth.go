@@ -0,0 +1,145 @@
+package player
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/ibrokemypie/kwatch/pkg/loopback"
+)
+
+// How a profile hands players the credentials for a media's URLs.
+const (
+	// ProxyAuth serves the media through a loopback proxy that adds them.
+	ProxyAuth = ""
+	// HeaderAuth passes an Authorization header with HeaderArgs. Players
+	// send it to every server they request, including ones redirected to,
+	// so media spanning servers is proxied instead.
+	HeaderAuth = "header"
+	// URLAuth puts them in the URLs, where they show up in ps and the
+	// player's logs.
+	URLAuth = "url"
+)
+
+var errNoHeaderArgs = errors.New("player profile uses header auth but has no HeaderArgs")
+
+// authPlayer is implemented by players that choose how they are given
+// credentials, others get a proxy.
+type authPlayer interface {
+	AuthMode() string
+}
+
+// handoffMode is how player is given media's credentials. Players send
+// header auth to every server they request, so media spanning servers is
+// proxied instead.
+func handoffMode(player Player, media *Media) string {
+	mode := ProxyAuth
+	if auth, ok := player.(authPlayer); ok {
+		mode = auth.AuthMode()
+	}
+
+	if mode == HeaderAuth && !media.singleHost() {
+		return ProxyAuth
+	}
+
+	return mode
+}
+
+// singleHost reports whether the media's URLs, subtitles and entries are all
+// on one server.
+func (m *Media) singleHost() bool {
+	target, err := url.Parse(m.URL)
+	if err != nil {
+		return false
+	}
+
+	return m.onHost(target)
+}
+
+func (m *Media) onHost(target *url.URL) bool {
+	for _, address := range append([]string{m.URL}, m.Subtitles...) {
+		parsed, err := url.Parse(address)
+		if err != nil || parsed.Scheme != target.Scheme || parsed.Host != target.Host {
+			return false
+		}
+	}
+
+	for _, entry := range m.Entries {
+		if !entry.onHost(target) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Media) hasCredentials() bool {
+	return len(m.Username) > 0 || len(m.Password) > 0
+}
+
+// authorization is the header value for the media's credentials.
+func (m *Media) authorization() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(m.Username+":"+m.Password))
+}
+
+// proxyCredentials points the media's URLs at a loopback proxy for their
+// server, which adds the credentials to each request.
+func proxyCredentials(media *Media) error {
+	for _, entry := range media.Entries {
+		err := proxyCredentials(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !media.hasCredentials() {
+		return nil
+	}
+
+	target, err := url.Parse(media.URL)
+	if err != nil {
+		return err
+	}
+
+	server, err := loopback.Serve(loopback.ProxyHandler(target, media.Username, media.Password))
+	if err != nil {
+		return err
+	}
+	media.AddCloser(server)
+
+	media.URL = proxiedURL(server, target, media.URL)
+	for i, subtitle := range media.Subtitles {
+		media.Subtitles[i] = proxiedURL(server, target, subtitle)
+	}
+
+	media.Username, media.Password = "", ""
+	return nil
+}
+
+// proxiedURL is address as requested through server, addresses on other
+// servers than target are left alone.
+func proxiedURL(server *loopback.Server, target *url.URL, address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Scheme != target.Scheme || parsed.Host != target.Host {
+		return address
+	}
+
+	proxied := server.URL(parsed.Path)
+	if len(parsed.RawQuery) > 0 {
+		proxied += "?" + parsed.RawQuery
+	}
+
+	return proxied
+}
+
+// credentialURL puts the media's credentials into address.
+func (m *Media) credentialURL(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil || !strings.HasPrefix(parsed.Scheme, "http") {
+		return address
+	}
+
+	parsed.User = url.UserPassword(m.Username, m.Password)
+	return parsed.String()
+}
@@ -0,0 +1,89 @@
+package player
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyHandoff(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	media := NewMedia(upstream.URL+"/Episode 1.mkv", "Episode 1.mkv")
+	media.Subtitles = []string{upstream.URL + "/Episode 1.srt", "https://elsewhere.tld/Episode 1.srt"}
+	media.Username, media.Password = "user", "secret"
+
+	err := proxyCredentials(media)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer media.Close()
+
+	if media.hasCredentials() || strings.HasPrefix(media.URL, upstream.URL) {
+		t.Fatalf("media still points at %s with credentials", media.URL)
+	}
+	if media.Subtitles[1] != "https://elsewhere.tld/Episode 1.srt" {
+		t.Fatalf("subtitle on another server became %s", media.Subtitles[1])
+	}
+
+	for address, want := range map[string]string{media.URL: "/Episode 1.mkv", media.Subtitles[0]: "/Episode 1.srt"} {
+		response, err := http.Get(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if string(body) != want {
+			t.Fatalf("%s proxied %s %q, want %q", address, response.Status, body, want)
+		}
+	}
+}
+
+func TestHeaderHandoff(t *testing.T) {
+	profile := Profile{
+		Name:       "mpv",
+		Command:    "mpv",
+		Args:       []string{"{url}"},
+		Auth:       HeaderAuth,
+		HeaderArgs: []string{"--http-header-fields={header}"},
+	}
+
+	media := NewMedia("https://host.tld/Episode 1.mkv", "Episode 1.mkv")
+	media.Username, media.Password = "user", "secret"
+
+	cmd, err := profile.BuildCommand(media)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"mpv", "--http-header-fields=Authorization: Basic dXNlcjpzZWNyZXQ=", "https://host.tld/Episode 1.mkv"}
+	if strings.Join(cmd.Args, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("args are %q, want %q", cmd.Args, want)
+	}
+}
+
+func TestHeaderAuthProxiedAcrossServers(t *testing.T) {
+	profile := Profile{Command: "mpv", Auth: HeaderAuth}
+
+	media := NewMedia("https://host.tld/Episode 1.mkv", "Episode 1.mkv")
+	media.Subtitles = []string{"https://host.tld/Episode 1.srt"}
+	if mode := handoffMode(profile, media); mode != HeaderAuth {
+		t.Fatalf("single server media handed off with %q, want header", mode)
+	}
+
+	playlist := NewPlaylist([]*Media{media, NewMedia("https://other.tld/Episode 2.mkv", "Episode 2.mkv")})
+	if mode := handoffMode(profile, playlist); mode != ProxyAuth {
+		t.Fatalf("media spanning servers handed off with %q, want the proxy", mode)
+	}
+}
@@ -0,0 +1,161 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// ipcDialTimeout is how long a player gets to create its IPC socket. Players
+// without IPC support never do, and are then run without it.
+const ipcDialTimeout = 5 * time.Second
+
+// observedProperties are the mpv properties kwatch follows, observe_property
+// ids are their index plus one.
+var observedProperties = []string{"time-pos", "duration", "pause", "path"}
+
+// PlaybackState is what a player last reported over IPC.
+type PlaybackState struct {
+	Path     string
+	Position time.Duration
+	Duration time.Duration
+	Paused   bool
+}
+
+// Progress is the fraction of the file played, or 0 when the duration is
+// unknown.
+func (s PlaybackState) Progress() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+
+	return float64(s.Position) / float64(s.Duration)
+}
+
+type ipcEvent struct {
+	Event string          `json:"event"`
+	Name  string          `json:"name"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// ipcClient speaks mpv's JSON IPC protocol, one JSON object per line.
+type ipcClient struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	stateMu sync.Mutex
+	state   PlaybackState
+}
+
+// dialIPC waits for the player to create its socket, giving up when done is
+// closed or the timeout passes.
+func dialIPC(socketPath string, done <-chan struct{}) (*ipcClient, error) {
+	deadline := time.Now().Add(ipcDialTimeout)
+
+	for {
+		conn, err := net.Dial("unix", socketPath)
+		if err == nil {
+			return newIPCClient(conn)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-done:
+			return nil, err
+
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func newIPCClient(conn net.Conn) (*ipcClient, error) {
+	c := &ipcClient{conn: conn}
+
+	for i, property := range observedProperties {
+		err := c.command("observe_property", i+1, property)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	go c.read()
+
+	return c, nil
+}
+
+func (c *ipcClient) command(args ...interface{}) error {
+	line, err := json.Marshal(map[string]interface{}{"command": args})
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = c.conn.Write(append(line, '\n'))
+	return err
+}
+
+func (c *ipcClient) read() {
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var event ipcEvent
+		if json.Unmarshal(scanner.Bytes(), &event) != nil || event.Event != "property-change" {
+			continue
+		}
+
+		// mpv unsets properties while a file ends or unloads, the last values
+		// are the ones worth keeping.
+		if len(event.Data) <= 0 || string(event.Data) == "null" {
+			continue
+		}
+
+		c.stateMu.Lock()
+		switch event.Name {
+		case "time-pos":
+			if position, ok := secondsDuration(event.Data); ok {
+				c.state.Position = position
+			}
+
+		case "duration":
+			if duration, ok := secondsDuration(event.Data); ok {
+				c.state.Duration = duration
+			}
+
+		case "pause":
+			json.Unmarshal(event.Data, &c.state.Paused)
+
+		case "path":
+			json.Unmarshal(event.Data, &c.state.Path)
+		}
+		c.stateMu.Unlock()
+	}
+}
+
+func (c *ipcClient) State() PlaybackState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	return c.state
+}
+
+func (c *ipcClient) Close() error {
+	return c.conn.Close()
+}
+
+// secondsDuration reads mpv's floating point seconds.
+func secondsDuration(data json.RawMessage) (time.Duration, bool) {
+	var seconds float64
+	if json.Unmarshal(data, &seconds) != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
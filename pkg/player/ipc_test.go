@@ -0,0 +1,103 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeMPV answers the observe_property commands and then sends events, like
+// mpv does over its IPC socket.
+func fakeMPV(t *testing.T, conn net.Conn, events []string) {
+	reader := bufio.NewReader(conn)
+	for range observedProperties {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Errorf("reading command: %s", err)
+			return
+		}
+
+		var command struct {
+			Command []interface{} `json:"command"`
+		}
+		if err := json.Unmarshal(line, &command); err != nil || command.Command[0] != "observe_property" {
+			t.Errorf("unexpected command %s", line)
+		}
+	}
+
+	for _, event := range events {
+		fmt.Fprintln(conn, event)
+	}
+}
+
+func waitForState(t *testing.T, c *ipcClient, want PlaybackState) {
+	deadline := time.Now().Add(time.Second)
+	for c.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state is %+v, want %+v", c.State(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestIPCClientFollowsProperties(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go fakeMPV(t, server, []string{
+		`{"event":"property-change","id":4,"name":"path","data":"http://host/ep1.mkv"}`,
+		`{"event":"property-change","id":2,"name":"duration","data":1440.5}`,
+		`{"event":"property-change","id":1,"name":"time-pos","data":600.25}`,
+		`{"event":"property-change","id":3,"name":"pause","data":true}`,
+		`{"request_id":0,"error":"success"}`,
+		`not json`,
+	})
+
+	c, err := newIPCClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	waitForState(t, c, PlaybackState{
+		Path:     "http://host/ep1.mkv",
+		Position: 600250 * time.Millisecond,
+		Duration: 1440500 * time.Millisecond,
+		Paused:   true,
+	})
+}
+
+func TestIPCClientKeepsValuesWhenUnset(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go fakeMPV(t, server, []string{
+		`{"event":"property-change","id":4,"name":"path","data":"http://host/ep1.mkv"}`,
+		`{"event":"property-change","id":2,"name":"duration","data":1440}`,
+		`{"event":"property-change","id":1,"name":"time-pos","data":1430}`,
+		// The file ends.
+		`{"event":"property-change","id":1,"name":"time-pos","data":null}`,
+		`{"event":"property-change","id":2,"name":"duration","data":null}`,
+		`{"event":"property-change","id":4,"name":"path"}`,
+		// Events are read in order, so once this one is seen the ones above
+		// have been too.
+		`{"event":"property-change","id":3,"name":"pause","data":true}`,
+	})
+
+	c, err := newIPCClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	want := PlaybackState{
+		Path:     "http://host/ep1.mkv",
+		Position: 1430 * time.Second,
+		Duration: 1440 * time.Second,
+		Paused:   true,
+	}
+	waitForState(t, c, want)
+}
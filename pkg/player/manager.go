@@ -0,0 +1,298 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stderrTailSize is how much of a player's stderr is kept to explain why it
+// failed.
+const stderrTailSize = 4096
+
+// Process is a player running in the background.
+type Process struct {
+	ID      int
+	Title   string
+	Started time.Time
+	cmd     *exec.Cmd
+	media   *Media
+	stderr  *tailBuffer
+	done    chan struct{}
+	err     error
+	ipcDir  string
+
+	// mu guards ipc and killed.
+	mu     sync.Mutex
+	ipc    *ipcClient
+	killed bool
+}
+
+var errNoIPC = errors.New("player cannot be controlled from kwatch")
+
+// Wait blocks until the player exits. Failures include the exit code and the
+// end of the player's stderr.
+func (p *Process) Wait() error {
+	<-p.done
+	return p.err
+}
+
+func (p *Process) Running() bool {
+	select {
+	case <-p.done:
+		return false
+
+	default:
+		return true
+	}
+}
+
+func (p *Process) Kill() error {
+	if !p.Running() {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.killed = true
+	p.mu.Unlock()
+
+	return p.cmd.Process.Kill()
+}
+
+// Killed reports whether the player was stopped from kwatch.
+func (p *Process) Killed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.killed
+}
+
+func (p *Process) client() *ipcClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.ipc
+}
+
+// State is the playback state reported over IPC, ok is false for players
+// without an IPC connection.
+func (p *Process) State() (state PlaybackState, ok bool) {
+	client := p.client()
+	if client == nil {
+		return PlaybackState{}, false
+	}
+
+	return client.State(), true
+}
+
+func (p *Process) TogglePause() error {
+	return p.command("cycle", "pause")
+}
+
+// Seek moves playback by offset from the current position.
+func (p *Process) Seek(offset time.Duration) error {
+	return p.command("seek", offset.Seconds(), "relative")
+}
+
+func (p *Process) Next() error {
+	return p.command("playlist-next")
+}
+
+func (p *Process) command(args ...interface{}) error {
+	client := p.client()
+	if client == nil {
+		return errNoIPC
+	}
+
+	return client.command(args...)
+}
+
+// connectIPC follows the player's IPC socket once it appears.
+func (p *Process) connectIPC() {
+	client, err := dialIPC(p.media.IPCSocket, p.done)
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.ipc = client
+	p.mu.Unlock()
+
+	if !p.Running() {
+		client.Close()
+	}
+}
+
+// Manager tracks the players kwatch has started, so several can run while
+// browsing continues.
+type Manager struct {
+	mu        sync.Mutex
+	processes []*Process
+	nextID    int
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Start runs media in player in the background and closes media once it
+// exits. Players that support IPC are given a socket to be controlled with.
+func (m *Manager) Start(player Player, media *Media) (*Process, error) {
+	ipcDir := ""
+	if ipc, ok := player.(ipcPlayer); ok && ipc.SupportsIPC() {
+		dir, err := os.MkdirTemp("", "kwatch-ipc")
+		if err == nil {
+			ipcDir = dir
+			media.IPCSocket = filepath.Join(dir, "mpv.sock")
+		}
+	}
+
+	if handoffMode(player, media) == ProxyAuth {
+		err := proxyCredentials(media)
+		if err != nil {
+			media.Close()
+			removeIPCDir(ipcDir)
+			return nil, err
+		}
+	}
+
+	cmd, err := player.BuildCommand(media)
+	if err != nil {
+		media.Close()
+		removeIPCDir(ipcDir)
+		return nil, err
+	}
+
+	process := &Process{
+		Title:   media.Title,
+		Started: time.Now(),
+		cmd:     cmd,
+		media:   media,
+		stderr:  &tailBuffer{},
+		done:    make(chan struct{}),
+		ipcDir:  ipcDir,
+	}
+	cmd.Stderr = process.stderr
+
+	err = cmd.Start()
+	if err != nil {
+		media.Close()
+		removeIPCDir(ipcDir)
+		return nil, fmt.Errorf("%s: %s", cmd.String(), err.Error())
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	process.ID = m.nextID
+	m.processes = append(m.processes, process)
+	m.mu.Unlock()
+
+	go m.wait(process)
+	if len(media.IPCSocket) > 0 {
+		go process.connectIPC()
+	}
+
+	return process, nil
+}
+
+// Processes returns the running players, oldest first.
+func (m *Manager) Processes() []*Process {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*Process{}, m.processes...)
+}
+
+func (m *Manager) Get(id int) *Process {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, process := range m.processes {
+		if process.ID == id {
+			return process
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) wait(process *Process) {
+	err := process.cmd.Wait()
+	process.media.Close()
+
+	if client := process.client(); client != nil {
+		client.Close()
+	}
+	removeIPCDir(process.ipcDir)
+
+	// Players stopped from kwatch have not failed.
+	process.mu.Lock()
+	if process.killed {
+		err = nil
+	}
+	process.mu.Unlock()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			err = fmt.Errorf("%s exited with status %d", process.cmd.Path, exitErr.ExitCode())
+		}
+
+		if tail := process.stderr.lastLines(3); len(tail) > 0 {
+			err = fmt.Errorf("%s: %s", err, tail)
+		}
+	}
+	process.err = err
+
+	m.mu.Lock()
+	for i, p := range m.processes {
+		if p == process {
+			m.processes = append(m.processes[:i], m.processes[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	close(process.done)
+}
+
+func removeIPCDir(dir string) {
+	if len(dir) > 0 {
+		os.RemoveAll(dir)
+	}
+}
+
+// tailBuffer keeps the last bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > stderrTailSize {
+		b.buf = b.buf[len(b.buf)-stderrTailSize:]
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) lastLines(n int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := strings.Split(strings.TrimSpace(string(b.buf)), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return strings.TrimSpace(strings.Join(lines, " / "))
+}
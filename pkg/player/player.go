@@ -0,0 +1,263 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media is a file ready to be handed to a player.
+type Media struct {
+	URL       string
+	Title     string
+	Subtitles []string
+	Start     time.Duration
+	// Args are extra arguments from the bookmark, added before the URL.
+	Args []string
+	// Username and Password are needed to request the URLs, the profile
+	// decides how the player is given them.
+	Username string
+	Password string
+	// IPCSocket is where the player should create its IPC socket, set by
+	// the Manager for players that support it.
+	IPCSocket string
+	// Entries are played in order instead of URL when the media is a
+	// playlist.
+	Entries []*Media
+	closers []io.Closer
+}
+
+func NewMedia(url, title string) *Media {
+	return &Media{URL: url, Title: title}
+}
+
+// NewPlaylist plays entries one after another, they are closed along with the
+// playlist.
+func NewPlaylist(entries []*Media) *Media {
+	playlist := &Media{URL: entries[0].URL, Title: entries[0].Title, Entries: entries}
+	if len(entries) > 1 {
+		playlist.Title += fmt.Sprintf(" (+%d more)", len(entries)-1)
+	}
+
+	for _, entry := range entries {
+		playlist.AddCloser(entry)
+	}
+
+	return playlist
+}
+
+// mediaExtensions are the files handed to players as part of a playlist.
+var mediaExtensions = map[string]bool{
+	".3gp": true, ".avi": true, ".flv": true, ".m2ts": true, ".m4v": true,
+	".mkv": true, ".mov": true, ".mp4": true, ".mpeg": true, ".mpg": true,
+	".ogv": true, ".ts": true, ".webm": true, ".wmv": true,
+	".aac": true, ".flac": true, ".m4a": true, ".mp3": true, ".ogg": true,
+	".opus": true, ".wav": true, ".wma": true,
+}
+
+// IsMedia guesses from its extension whether name is a video or audio file.
+func IsMedia(name string) bool {
+	return mediaExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// AddCloser ties c, like a loopback server, to the media's lifetime.
+func (m *Media) AddCloser(c io.Closer) {
+	m.closers = append(m.closers, c)
+}
+
+// Close releases everything the media needed while playing.
+func (m *Media) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if closeErr := c.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	m.closers = nil
+
+	return err
+}
+
+type Player interface {
+	BuildCommand(media *Media) (*exec.Cmd, error)
+}
+
+// ipcPlayer is implemented by players that can be controlled over mpv's JSON
+// IPC protocol.
+type ipcPlayer interface {
+	SupportsIPC() bool
+}
+
+// Profile builds a player command from argument templates. Args may use {url}
+// and {title}, the optional argument lists are only added when there is a
+// value for them. IPCArgs take {ipc}, the path of an mpv JSON IPC socket.
+// Playlists repeat the arguments using {url} for every entry, and leave out
+// the title, start and subtitle arguments which would apply to all of them.
+// Auth is one of the auth modes, HeaderArgs take {header}, a complete
+// Authorization header.
+type Profile struct {
+	Name         string
+	Command      string
+	Args         []string
+	TitleArgs    []string
+	StartArgs    []string
+	SubtitleArgs []string
+	IPCArgs      []string
+	Auth         string
+	HeaderArgs   []string
+	Env          []string
+}
+
+// Profiles for players kwatch knows out of the box, used when no profile of
+// the same name is configured.
+var BuiltinProfiles = []Profile{
+	{
+		Name:         "mpv",
+		Command:      "mpv",
+		Args:         []string{"{url}"},
+		TitleArgs:    []string{"--force-media-title={title}"},
+		StartArgs:    []string{"--start={start}"},
+		SubtitleArgs: []string{"--sub-file={subtitle}"},
+		IPCArgs:      []string{"--input-ipc-server={ipc}"},
+		HeaderArgs:   []string{"--http-header-fields={header}"},
+	},
+	{
+		Name:         "vlc",
+		Command:      "vlc",
+		Args:         []string{"{url}"},
+		TitleArgs:    []string{"--meta-title={title}"},
+		StartArgs:    []string{"--start-time={start}"},
+		SubtitleArgs: []string{"--sub-file={subtitle}"},
+	},
+	{
+		Name:         "mplayer",
+		Command:      "mplayer",
+		Args:         []string{"{url}"},
+		TitleArgs:    []string{"-title", "{title}"},
+		StartArgs:    []string{"-ss", "{start}"},
+		SubtitleArgs: []string{"-sub", "{subtitle}"},
+	},
+	{
+		Name:         "iina",
+		Command:      "iina",
+		Args:         []string{"{url}"},
+		TitleArgs:    []string{"--mpv-force-media-title={title}"},
+		StartArgs:    []string{"--mpv-start={start}"},
+		SubtitleArgs: []string{"--mpv-sub-file={subtitle}"},
+		IPCArgs:      []string{"--mpv-input-ipc-server={ipc}"},
+		HeaderArgs:   []string{"--mpv-http-header-fields={header}"},
+	},
+}
+
+// DefaultProfile plays files for bookmarks without a player.
+const DefaultProfile = "mpv"
+
+// CommandProfile runs command with the URL as its only argument, which is
+// how a player that has no profile is started.
+func CommandProfile(command string) Profile {
+	return Profile{Name: command, Command: command, Args: []string{"{url}"}}
+}
+
+func (p Profile) AuthMode() string {
+	return p.Auth
+}
+
+func (p Profile) SupportsIPC() bool {
+	return len(p.IPCArgs) > 0
+}
+
+func (p Profile) BuildCommand(media *Media) (*exec.Cmd, error) {
+	if len(p.Command) <= 0 {
+		return nil, errors.New("player profile " + p.Name + " has no command")
+	}
+
+	entries := media.Entries
+	if len(entries) <= 0 {
+		entries = []*Media{media}
+	}
+
+	urlFor := func(entry *Media, address string) string {
+		if p.Auth == URLAuth && entry.hasCredentials() {
+			return entry.credentialURL(address)
+		}
+
+		return address
+	}
+
+	replacer := strings.NewReplacer(
+		"{url}", urlFor(media, media.URL),
+		"{title}", media.Title,
+		"{start}", strconv.FormatFloat(media.Start.Seconds(), 'f', 0, 64),
+		"{ipc}", media.IPCSocket,
+	)
+
+	isPlaylist := len(media.Entries) > 0
+
+	var args []string
+	if len(media.Title) > 0 && !isPlaylist {
+		args = append(args, expandArgs(replacer, p.TitleArgs)...)
+	}
+
+	if media.Start > 0 && !isPlaylist {
+		args = append(args, expandArgs(replacer, p.StartArgs)...)
+	}
+
+	if len(media.IPCSocket) > 0 {
+		args = append(args, expandArgs(replacer, p.IPCArgs)...)
+	}
+
+	if p.Auth == HeaderAuth && entries[0].hasCredentials() {
+		if len(p.HeaderArgs) <= 0 {
+			return nil, errNoHeaderArgs
+		}
+
+		header := "Authorization: " + entries[0].authorization()
+		args = append(args, expandArgs(strings.NewReplacer("{header}", header), p.HeaderArgs)...)
+	}
+
+	if !isPlaylist {
+		for _, subtitle := range media.Subtitles {
+			args = append(args, expandArgs(strings.NewReplacer("{subtitle}", urlFor(media, subtitle)), p.SubtitleArgs)...)
+		}
+	}
+
+	args = append(args, media.Args...)
+
+	templateArgs := p.Args
+	if len(templateArgs) <= 0 {
+		templateArgs = []string{"{url}"}
+	}
+
+	for _, template := range templateArgs {
+		if !isPlaylist || !strings.Contains(template, "{url}") {
+			args = append(args, replacer.Replace(template))
+			continue
+		}
+
+		for _, entry := range media.Entries {
+			args = append(args, strings.NewReplacer("{url}", urlFor(entry, entry.URL), "{title}", entry.Title).Replace(template))
+		}
+	}
+
+	runCMD := exec.Command(p.Command, args...)
+	if len(p.Env) > 0 {
+		runCMD.Env = append(os.Environ(), p.Env...)
+	}
+
+	return runCMD, nil
+}
+
+func expandArgs(replacer *strings.Replacer, templates []string) []string {
+	args := make([]string, len(templates))
+	for i, template := range templates {
+		args[i] = replacer.Replace(template)
+	}
+
+	return args
+}
@@ -0,0 +1,251 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/loopback"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+// archiveSource lets the archives in another source be entered like
+// directories. Outside of an archive every call goes to the wrapped source.
+type archiveSource struct {
+	Source
+	archive     *archive
+	archiveName string
+	innerPath   []string
+	// archives are the archive names in the wrapped source's current
+	// directory, nil until it has been listed.
+	archives map[string]bool
+}
+
+func (s *archiveSource) inArchive() bool {
+	return len(s.archiveName) > 0
+}
+
+func (s *archiveSource) ChangeDir(dir string) {
+	switch {
+	case s.inArchive() && dir == "..":
+		if len(s.innerPath) > 0 {
+			s.innerPath = s.innerPath[:len(s.innerPath)-1]
+		} else {
+			s.closeArchive()
+		}
+
+	case s.inArchive():
+		s.innerPath = append(s.innerPath, dir)
+
+	case s.archives[dir] || (s.archives == nil && isArchive(dir)):
+		// The archive is read by the next GetItems, off the UI goroutine,
+		// which also checks unlisted names are not directories.
+		s.archiveName = dir
+
+	default:
+		s.Source.ChangeDir(dir)
+		s.archives = nil
+	}
+}
+
+func (s *archiveSource) GetItems() ([]list.Item, error) {
+	if !s.inArchive() {
+		listItems, err := markArchives(s.Source.GetItems())
+		if err != nil {
+			return nil, err
+		}
+
+		s.archives = archiveNames(listItems)
+		return listItems, nil
+	}
+
+	if s.archives == nil {
+		listItems, err := markArchives(s.Source.GetItems())
+		if err != nil {
+			s.closeArchive()
+			return nil, err
+		}
+		s.archives = archiveNames(listItems)
+
+		// Entered by name before the listing was known, as when restoring
+		// a path, and turned out to be a directory.
+		if !s.archives[s.archiveName] {
+			dir, innerPath := s.archiveName, s.innerPath
+			s.closeArchive()
+
+			s.ChangeDir(dir)
+			for _, dir := range innerPath {
+				s.ChangeDir(dir)
+			}
+
+			return s.GetItems()
+		}
+	}
+
+	if s.archive == nil {
+		reader, ok := s.Source.(sourceFile.Reader)
+		if !ok {
+			s.closeArchive()
+			return nil, errors.New("archives cannot be opened from this source")
+		}
+
+		archive, err := openArchive(reader, s.archiveName)
+		if err != nil {
+			s.closeArchive()
+			return nil, err
+		}
+
+		s.archive = archive
+	}
+
+	return s.archiveItems(s.innerPath), nil
+}
+
+func (s *archiveSource) ListDir(dir string) ([]list.Item, error) {
+	if !s.inArchive() {
+		return markArchives(s.Source.ListDir(dir))
+	}
+
+	// Members are only listed once the archive has been opened by GetItems.
+	if s.archive == nil {
+		return nil, fmt.Errorf("%s: not opened", s.archiveName)
+	}
+
+	return s.archiveItems(append(append([]string{}, s.innerPath...), dir)), nil
+}
+
+// markArchives sets the listing type of archives in a listing, so they are
+// entered instead of played.
+func markArchives(listItems []list.Item, err error) ([]list.Item, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	for i, listItem := range listItems {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.ListingType == "file" && isArchive(item.Path) {
+			item.ListingType = "archive"
+			listItems[i] = item
+		}
+	}
+
+	return listItems, nil
+}
+
+func archiveNames(listItems []list.Item) map[string]bool {
+	names := map[string]bool{}
+
+	for _, listItem := range listItems {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.ListingType == "archive" {
+			names[item.Path] = true
+		}
+	}
+
+	return names
+}
+
+func (s *archiveSource) OpenFile(filePath string) (*player.Media, error) {
+	if !s.inArchive() || s.archive == nil {
+		return s.Source.OpenFile(filePath)
+	}
+
+	entry, ok := s.archive.entry(path.Join(append(s.innerPath, filePath)...))
+	if !ok {
+		return nil, fmt.Errorf("%s: not found in %s", filePath, s.archiveName)
+	}
+
+	name := path.Base(entry.name)
+	handler := loopback.StreamHandler(entry.size, entry.open)
+	if entry.openSeeker != nil {
+		handler = loopback.FileHandler(name, entry.modTime, entry.openSeeker)
+	}
+
+	server, err := loopback.Serve(handler)
+	if err != nil {
+		return nil, err
+	}
+
+	media := player.NewMedia(server.URL(name), filePath)
+	media.AddCloser(server)
+
+	return media, nil
+}
+
+func (s *archiveSource) GetPathString() string {
+	if !s.inArchive() {
+		return s.Source.GetPathString()
+	}
+
+	return strings.Join(append([]string{s.Source.GetPathString(), s.archiveName}, s.innerPath...), "/")
+}
+
+func (s *archiveSource) Close() error {
+	s.closeArchive()
+	return s.Source.Close()
+}
+
+func (s *archiveSource) closeArchive() {
+	if s.archive != nil {
+		s.archive.Close()
+	}
+
+	s.archive = nil
+	s.archiveName = ""
+	s.innerPath = nil
+}
+
+// archiveItems lists the members directly inside innerPath, adding
+// directories that only exist as part of member names.
+func (s *archiveSource) archiveItems(innerPath []string) []list.Item {
+	prefix := strings.Join(innerPath, "/")
+	if len(prefix) > 0 {
+		prefix += "/"
+	}
+
+	dirs := map[string]bool{}
+	var items []sourceItem.Item
+
+	for _, entry := range s.archive.entries {
+		if len(entry.name) <= 0 || !strings.HasPrefix(entry.name, prefix) || entry.name == strings.TrimSuffix(prefix, "/") {
+			continue
+		}
+
+		name := strings.TrimPrefix(entry.name, prefix)
+		if i := strings.Index(name, "/"); i >= 0 || entry.isDir {
+			if i >= 0 {
+				name = name[:i]
+			}
+
+			if !dirs[name] {
+				dirs[name] = true
+				items = append(items, sourceItem.Item{ListingType: "dir", Name: name + "/", Path: name})
+			}
+			continue
+		}
+
+		items = append(items, sourceItem.Item{
+			ListingType: "file",
+			Name:        name,
+			Path:        name,
+			Size:        entry.size,
+			ModTime:     entry.modTime,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	listItems := []list.Item{sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."}}
+	for _, item := range items {
+		listItems = append(listItems, item)
+	}
+
+	return listItems
+}
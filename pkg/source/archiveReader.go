@@ -0,0 +1,368 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/klauspost/compress/zstd"
+)
+
+// readAheadSize is how much is fetched for each read from an archive, so
+// parsing and streaming do not make a request per small read.
+const readAheadSize = 1 << 20
+
+type archiveEntry struct {
+	name    string
+	isDir   bool
+	size    int64
+	modTime time.Time
+
+	// openSeeker is set for members stored uncompressed, which players can
+	// seek in. Other members can only be streamed with open.
+	openSeeker func() (io.ReadSeekCloser, error)
+	open       func() (io.ReadCloser, error)
+}
+
+type archive struct {
+	file    sourceFile.File
+	entries []archiveEntry
+}
+
+func (a *archive) Close() error {
+	return a.file.Close()
+}
+
+func (a *archive) entry(name string) (archiveEntry, bool) {
+	for _, entry := range a.entries {
+		if entry.name == name {
+			return entry, true
+		}
+	}
+
+	return archiveEntry{}, false
+}
+
+func isArchive(name string) bool {
+	return len(archiveFormat(name)) > 0
+}
+
+func archiveFormat(name string) string {
+	name = strings.ToLower(name)
+
+	for _, suffix := range []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.zst"} {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+
+	return ""
+}
+
+// memberName normalises member paths, tar archives made from "." prefix every
+// member with "./".
+func memberName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+}
+
+// openArchive reads the members of an archive. Zip archives only need their
+// central directory, tar archives are read from start to end.
+func openArchive(reader sourceFile.Reader, filePath string) (*archive, error) {
+	file, err := reader.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reopen := func() (sourceFile.File, error) {
+		return reader.ReadFile(filePath)
+	}
+
+	var entries []archiveEntry
+
+	switch archiveFormat(filePath) {
+	case ".zip":
+		entries, err = readZip(file, reopen)
+
+	case ".tar":
+		entries, err = readTar(file, reopen)
+
+	default:
+		entries, err = readCompressedTar(file, filePath, reopen)
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %s", filePath, err)
+	}
+
+	return &archive{file, entries}, nil
+}
+
+func readZip(file sourceFile.File, reopen func() (sourceFile.File, error)) ([]archiveEntry, error) {
+	zipReader, err := zip.NewReader(newReadAhead(file), file.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	for i, zipFile := range zipReader.File {
+		entry := archiveEntry{
+			name:    memberName(zipFile.Name),
+			isDir:   zipFile.FileInfo().IsDir(),
+			size:    int64(zipFile.UncompressedSize64),
+			modTime: zipFile.Modified,
+			open:    zipMemberOpener(reopen, i),
+		}
+
+		if offset, err := zipFile.DataOffset(); err == nil && zipFile.Method == zip.Store {
+			entry.openSeeker = sectionOpener(reopen, offset, entry.size)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// zipMemberOpener opens the index'th member from a handle of its own, so it
+// can still be played after the archive is left.
+func zipMemberOpener(reopen func() (sourceFile.File, error), index int) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		file, err := reopen()
+		if err != nil {
+			return nil, err
+		}
+
+		zipReader, err := zip.NewReader(newReadAhead(file), file.Size())
+		if err == nil && index >= len(zipReader.File) {
+			err = fmt.Errorf("archive changed since it was opened")
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		member, err := zipReader.File[index].Open()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		return readCloser{member, func() error {
+			member.Close()
+			return file.Close()
+		}}, nil
+	}
+}
+
+func readTar(file sourceFile.File, reopen func() (sourceFile.File, error)) ([]archiveEntry, error) {
+	// tar seeks over member data when it can, so only headers are fetched.
+	tarReader := tar.NewReader(file)
+
+	var entries []archiveEntry
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, archiveEntry{
+			name:       memberName(header.Name),
+			isDir:      header.Typeflag == tar.TypeDir,
+			size:       header.Size,
+			modTime:    header.ModTime,
+			openSeeker: sectionOpener(reopen, offset, header.Size),
+		})
+	}
+
+	return entries, nil
+}
+
+func readCompressedTar(file sourceFile.File, filePath string, reopen func() (sourceFile.File, error)) ([]archiveEntry, error) {
+	tarReader, closer, err := decompressTar(file, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	var entries []archiveEntry
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := header.Name
+		entries = append(entries, archiveEntry{
+			name:    memberName(name),
+			isDir:   header.Typeflag == tar.TypeDir,
+			size:    header.Size,
+			modTime: header.ModTime,
+			open: func() (io.ReadCloser, error) {
+				return compressedTarMember(reopen, filePath, name)
+			},
+		})
+	}
+
+	return entries, nil
+}
+
+// compressedTarMember decompresses the archive again up to the member, there
+// is no way to start part way through.
+func compressedTarMember(reopen func() (sourceFile.File, error), filePath, name string) (io.ReadCloser, error) {
+	file, err := reopen()
+	if err != nil {
+		return nil, err
+	}
+
+	tarReader, closer, err := decompressTar(file, filePath)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			closer()
+			file.Close()
+			return nil, err
+		}
+
+		if header.Name == name {
+			return readCloser{tarReader, func() error {
+				closer()
+				return file.Close()
+			}}, nil
+		}
+	}
+}
+
+func decompressTar(file sourceFile.File, filePath string) (*tar.Reader, func(), error) {
+	switch archiveFormat(filePath) {
+	case ".tar.zst":
+		decoder, err := zstd.NewReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return tar.NewReader(decoder), decoder.Close, nil
+
+	default:
+		decoder, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return tar.NewReader(decoder), func() { decoder.Close() }, nil
+	}
+}
+
+// sectionOpener opens an uncompressed member with a handle of its own, so
+// several player requests can read at once.
+func sectionOpener(reopen func() (sourceFile.File, error), offset, size int64) func() (io.ReadSeekCloser, error) {
+	return func() (io.ReadSeekCloser, error) {
+		file, err := reopen()
+		if err != nil {
+			return nil, err
+		}
+
+		section := io.NewSectionReader(newReadAhead(file), offset, size)
+		return readSeekCloser{section, file.Close}, nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	close func() error
+}
+
+func (r readSeekCloser) Close() error {
+	return r.close()
+}
+
+// readAhead serves small reads from one larger cached block.
+type readAhead struct {
+	reader      io.ReaderAt
+	size        int64
+	block       []byte
+	blockOffset int64
+	mu          sync.Mutex
+}
+
+func newReadAhead(file sourceFile.File) *readAhead {
+	return &readAhead{reader: file, size: file.Size(), blockOffset: -1}
+}
+
+func (r *readAhead) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Reads larger than a block gain nothing from the cache.
+	if len(p) > readAheadSize {
+		return r.reader.ReadAt(p, off)
+	}
+
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+
+		if r.blockOffset < 0 || off < r.blockOffset || off >= r.blockOffset+int64(len(r.block)) {
+			err := r.fill(off)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		copied := copy(p[n:], r.block[off-r.blockOffset:])
+		n += copied
+		off += int64(copied)
+	}
+
+	return n, nil
+}
+
+func (r *readAhead) fill(off int64) error {
+	blockSize := int64(readAheadSize)
+	if off+blockSize > r.size {
+		blockSize = r.size - off
+	}
+
+	block := make([]byte, blockSize)
+	n, err := r.reader.ReadAt(block, off)
+	if n <= 0 && err != nil {
+		return err
+	}
+
+	r.block = block[:n]
+	r.blockOffset = off
+	return nil
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/fileSource"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+func writeZip(t *testing.T, zipPath string, names ...string) {
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, name := range names {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func itemNames(listItems []list.Item) []string {
+	names := []string{}
+	for _, listItem := range listItems {
+		names = append(names, listItem.(sourceItem.Item).Name)
+	}
+	return names
+}
+
+func expectItems(t *testing.T, s Source, want ...string) {
+	t.Helper()
+
+	listItems, err := s.GetItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := itemNames(listItems)
+	if len(got) != len(want) {
+		t.Fatalf("listed %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("listed %q, want %q", got, want)
+		}
+	}
+}
+
+func archiveTree(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, "Season.zip/Episode 1.mkv")
+	writeZip(t, filepath.Join(dir, "Show.zip"), "Episode 2.mkv")
+	return dir
+}
+
+func TestDirectoriesNamedLikeArchivesAreEntered(t *testing.T) {
+	dir := archiveTree(t)
+	s := NewSource(bookmark.Bookmark{Backend: bookmark.File, Address: "file://", Path: dir})
+
+	expectItems(t, s, "..", "Season.zip/", "Show.zip")
+
+	s.ChangeDir("Season.zip")
+	expectItems(t, s, "..", "Episode 1.mkv")
+
+	s.ChangeDir("..")
+	s.ChangeDir("Show.zip")
+	expectItems(t, s, "..", "Episode 2.mkv")
+}
+
+func TestSourceAtChecksArchiveNames(t *testing.T) {
+	dir := archiveTree(t)
+	b := bookmark.Bookmark{Backend: bookmark.File, Address: "file://"}
+
+	expectItems(t, NewSourceAt(b, dir+"/Season.zip"), "..", "Episode 1.mkv")
+	expectItems(t, NewSourceAt(b, dir+"/Show.zip"), "..", "Episode 2.mkv")
+}
+
+func TestDeflatedMemberOutlivesArchive(t *testing.T) {
+	content := make([]byte, 4<<20)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	file, err := os.Create(filepath.Join(dir, "Show.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	member, err := writer.CreateHeader(&zip.FileHeader{Name: "Episode 1.mkv", Method: zip.Deflate})
+	if err == nil {
+		_, err = member.Write(content)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := fileSource.NewFileSource(bookmark.Bookmark{Backend: bookmark.File, Address: "file://"}, []string{strings.TrimPrefix(dir, "/")})
+	a, err := openArchive(reader, "Show.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := a.entry("Episode 1.mkv")
+	if !ok || entry.openSeeker != nil {
+		t.Fatal("deflated member not found or taken for a stored one")
+	}
+
+	stream, err := entry.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	start := make([]byte, 1024)
+	if _, err := io.ReadFull(stream, start); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leaving the archive closes it while a player is still streaming.
+	a.Close()
+
+	rest, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("reading after the archive was closed: %s", err)
+	}
+	if !bytes.Equal(append(start, rest...), content) {
+		t.Fatal("streamed member differs from what was archived")
+	}
+}
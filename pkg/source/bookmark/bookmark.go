@@ -1,6 +1,8 @@
 package bookmark
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"strings"
@@ -10,54 +12,209 @@ type BackendType int
 
 const (
 	HTTP BackendType = iota
+	File
+	SFTP
+	FTP
+	WebDAV
+	S3
+)
+
+// TLS modes for backends that can run with or without it.
+const (
+	NoTLS       = ""
+	ExplicitTLS = "explicit"
+	ImplicitTLS = "implicit"
 )
 
 type Bookmark struct {
-	Backend    BackendType
-	Address    string
-	Path       string
-	Username   string
-	Password   string
-	FileViewer string
+	// ID stays the same while the bookmark is edited or moved.
+	ID string
+	// Name is shown instead of the address when set.
+	Name string
+	// Group and Tags are shown under the bookmark in the picker, and can be
+	// filtered by.
+	Group         string
+	Tags          []string
+	Backend       BackendType
+	Address       string
+	Path          string
+	Username      string
+	Password      string
+	FileViewer    string
+	ListingFormat string
+	KeyFile       string
+	TLSMode       string
+	Region        string
+	// URLExpiry is how long presigned S3 URLs handed to the player stay
+	// usable, as a Go duration.
+	URLExpiry string
+	// PlayerArgs are passed to the player along with its profile's
+	// arguments.
+	PlayerArgs []string
+	// Autoplay opens the next file in the directory when a player finishes.
+	Autoplay bool
 }
 
 func (b Bookmark) Title() string {
+	if len(b.Name) > 0 {
+		return b.Name
+	}
+
+	return b.Location()
+}
+
+// Location is where the bookmark opens.
+func (b Bookmark) Location() string {
 	return b.Address + b.Path
 }
 
 func (b Bookmark) Description() string {
-	return ""
+	var parts []string
+
+	if len(b.Name) > 0 {
+		parts = append(parts, b.Location())
+	}
+	if len(b.Group) > 0 {
+		parts = append(parts, b.Group)
+	}
+	if len(b.Tags) > 0 {
+		parts = append(parts, b.tagString())
+	}
+
+	return strings.Join(parts, " · ")
 }
 
+// FilterValue includes the address, group and tags as well as the name, tags
+// can be filtered by as "#tag".
 func (b Bookmark) FilterValue() string {
-	return b.Title()
+	return strings.Join([]string{b.Name, b.Location(), b.Group, b.tagString()}, " ")
+}
+
+// HasTag reports whether the bookmark is tagged tag, ignoring case.
+func (b Bookmark) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b Bookmark) tagString() string {
+	tags := make([]string, len(b.Tags))
+	for i, tag := range b.Tags {
+		tags[i] = "#" + tag
+	}
+
+	return strings.Join(tags, " ")
+}
+
+// ParseTags splits a comma separated list of tags.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
 }
 
-func NewBookmark(address *url.URL, path, username, password string) (Bookmark, error) {
-	var backend BackendType
+// NewID makes a random ID for a bookmark.
+func NewID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
 
-	switch address.Scheme {
+	return hex.EncodeToString(id)
+}
+
+// SetAddress points the bookmark at address, picking the backend from its
+// scheme.
+func (b *Bookmark) SetAddress(address string) error {
+	addressURL, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+
+	if len(addressURL.Scheme) <= 0 {
+		return fmt.Errorf("Address requires scheme (http/https/webdav/webdavs/s3/file/sftp/ftp/ftps)")
+	}
+
+	if addressURL.User != nil {
+		return fmt.Errorf("Credentials belong in the username and password fields")
+	}
+
+	switch addressURL.Scheme {
 	case "http", "https":
-		backend = HTTP
+		b.Backend = HTTP
+
+	case "file":
+		b.Backend = File
+
+		// Local paths are usually typed straight into the address.
+		if len(addressURL.Path) > 0 {
+			b.Path = strings.TrimSuffix(addressURL.Path, "/")
+		}
+
+	case "sftp":
+		b.Backend = SFTP
+
+	case "ftp":
+		b.Backend = FTP
+
+	case "ftps":
+		b.Backend = FTP
+		b.TLSMode = ImplicitTLS
+
+	// WebDAV and S3 servers are plain HTTP servers, these schemes are kept
+	// to pick the backend and swapped by TransportURL.
+	case "webdav", "webdavs":
+		b.Backend = WebDAV
+
+	case "s3", "s3+http":
+		b.Backend = S3
 
 	default:
-		return Bookmark{}, fmt.Errorf("Unsupported backend: %s", address.Scheme)
+		return fmt.Errorf("Unsupported backend: %s", addressURL.Scheme)
+	}
+
+	addressURL.Path = ""
+	addressURL.RawPath = ""
+	addressURL.RawQuery = ""
+	addressURL.User = nil
+	addressURL.Fragment = ""
+	addressURL.Host = strings.TrimSuffix(addressURL.Host, "/")
+
+	b.Address = addressURL.String()
+	if b.Backend == File {
+		b.Address = "file://"
 	}
 
-	address.Path = ""
-	address.RawQuery = ""
-	address.User = nil
-	address.Fragment = ""
-	address.Host = strings.TrimSuffix(address.Host, "/")
+	return nil
+}
+
+// transportSchemes are the HTTP schemes behind the schemes that pick a
+// backend served over HTTP.
+var transportSchemes = map[string]string{
+	"webdav":  "http",
+	"webdavs": "https",
+	"s3":      "https",
+	"s3+http": "http",
+}
 
-	path = strings.TrimSuffix(path, "/")
+// TransportURL is the address backends send requests to.
+func (b Bookmark) TransportURL() (*url.URL, error) {
+	addressURL, err := url.Parse(b.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme, ok := transportSchemes[addressURL.Scheme]; ok {
+		addressURL.Scheme = scheme
+	}
 
-	return Bookmark{
-		Backend:    backend,
-		Address:    address.String(),
-		Path:       path,
-		Username:   username,
-		Password:   password,
-		FileViewer: "mpv",
-	}, nil
+	return addressURL, nil
 }
@@ -0,0 +1,42 @@
+package bookmark
+
+import "testing"
+
+func TestAddressRoundTrips(t *testing.T) {
+	tests := []struct {
+		address   string
+		backend   BackendType
+		stored    string
+		transport string
+	}{
+		{"https://files.host.tld/shows", HTTP, "https://files.host.tld", "https://files.host.tld"},
+		{"webdav://nas.lan:8080/", WebDAV, "webdav://nas.lan:8080", "http://nas.lan:8080"},
+		{"webdavs://nas.lan", WebDAV, "webdavs://nas.lan", "https://nas.lan"},
+		{"s3://s3.eu-west-1.amazonaws.com/media", S3, "s3://s3.eu-west-1.amazonaws.com", "https://s3.eu-west-1.amazonaws.com"},
+		{"s3+http://minio.lan:9000", S3, "s3+http://minio.lan:9000", "http://minio.lan:9000"},
+		{"sftp://seedbox.tld:2222", SFTP, "sftp://seedbox.tld:2222", "sftp://seedbox.tld:2222"},
+	}
+
+	for _, test := range tests {
+		var b Bookmark
+		if err := b.SetAddress(test.address); err != nil {
+			t.Fatalf("SetAddress(%s): %s", test.address, err)
+		}
+
+		if b.Backend != test.backend || b.Address != test.stored {
+			t.Errorf("SetAddress(%s) stored backend %d at %s, want %d at %s", test.address, b.Backend, b.Address, test.backend, test.stored)
+		}
+
+		transport, err := b.TransportURL()
+		if err != nil || transport.String() != test.transport {
+			t.Errorf("%s is requested at %s, want %s", b.Address, transport, test.transport)
+		}
+
+		// Saving the bookmark again from the editor keeps its backend.
+		address := LocationFields[1]
+		saved := b
+		if err := address.Set(&saved, address.Get(b)); err != nil || saved.Backend != b.Backend || saved.Address != b.Address {
+			t.Errorf("%s saved again as backend %d at %s", b.Address, saved.Backend, saved.Address)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package bookmark
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type FieldKind int
+
+const (
+	TextField FieldKind = iota
+	SecretField
+	// ChoiceField values are picked from Choices, the first being the
+	// default.
+	ChoiceField
+)
+
+// Field is a bookmark setting shown in the editor. Key identifies the setting
+// across backends, so values are kept when the address changes backend.
+type Field struct {
+	Key         string
+	Label       string
+	Placeholder string
+	Kind        FieldKind
+	Choices     []string
+	Get         func(b Bookmark) string
+	Set         func(b *Bookmark, value string) error
+}
+
+var yesNo = []string{"no", "yes"}
+
+// LocationFields come first for every backend, the address picks the
+// backend and so the rest of the fields.
+var LocationFields = []Field{
+	{
+		Key:         "name",
+		Label:       "Name",
+		Placeholder: "Media server",
+		Get:         func(b Bookmark) string { return b.Name },
+		Set: func(b *Bookmark, value string) error {
+			b.Name = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	{
+		Key:         "address",
+		Label:       "Address",
+		Placeholder: "https://files.hostname.tld",
+		Get:         func(b Bookmark) string { return b.Address },
+		Set:         func(b *Bookmark, value string) error { return b.SetAddress(value) },
+	},
+	{
+		Key:         "path",
+		Label:       "Path",
+		Placeholder: "/home/media",
+		Get:         func(b Bookmark) string { return b.Path },
+		Set: func(b *Bookmark, value string) error {
+			if len(value) > 0 && !strings.HasPrefix(value, "/") {
+				return fmt.Errorf("Path must start with /")
+			}
+
+			// Local paths are usually typed straight into the address.
+			if len(value) > 0 || b.Backend != File {
+				b.Path = strings.TrimSuffix(value, "/")
+			}
+			return nil
+		},
+	},
+}
+
+// PlayerFields and GroupFields follow the backend's fields.
+var PlayerFields = []Field{
+	{
+		Key:         "player",
+		Label:       "Player",
+		Placeholder: "mpv",
+		Get:         func(b Bookmark) string { return b.FileViewer },
+		Set: func(b *Bookmark, value string) error {
+			b.FileViewer = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	{
+		Key:         "playerArgs",
+		Label:       "Player arguments",
+		Placeholder: "--fullscreen",
+		Get:         func(b Bookmark) string { return JoinArgs(b.PlayerArgs) },
+		Set: func(b *Bookmark, value string) error {
+			args, err := SplitArgs(value)
+			if err != nil {
+				return err
+			}
+
+			b.PlayerArgs = args
+			return nil
+		},
+	},
+	{
+		Key:     "autoplay",
+		Label:   "Autoplay next",
+		Kind:    ChoiceField,
+		Choices: yesNo,
+		Get:     func(b Bookmark) string { return yesNo[boolIndex(b.Autoplay)] },
+		Set: func(b *Bookmark, value string) error {
+			b.Autoplay = value == "yes"
+			return nil
+		},
+	},
+}
+
+var GroupFields = []Field{
+	{
+		Key:         "group",
+		Label:       "Group",
+		Placeholder: "Work",
+		Get:         func(b Bookmark) string { return b.Group },
+		Set: func(b *Bookmark, value string) error {
+			b.Group = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	{
+		Key:         "tags",
+		Label:       "Tags",
+		Placeholder: "anime, shows",
+		Get:         func(b Bookmark) string { return strings.Join(b.Tags, ", ") },
+		Set: func(b *Bookmark, value string) error {
+			b.Tags = ParseTags(value)
+			return nil
+		},
+	},
+}
+
+// UsernameField and PasswordField are the credentials most backends take,
+// under labels that suit the backend.
+func UsernameField(label string) Field {
+	return Field{
+		Key:         "username",
+		Label:       label,
+		Placeholder: "root",
+		Get:         func(b Bookmark) string { return b.Username },
+		Set: func(b *Bookmark, value string) error {
+			b.Username = value
+			return nil
+		},
+	}
+}
+
+func PasswordField(label string) Field {
+	return Field{
+		Key:         "password",
+		Label:       label,
+		Placeholder: "toor",
+		Kind:        SecretField,
+		Get:         func(b Bookmark) string { return b.Password },
+		Set: func(b *Bookmark, value string) error {
+			b.Password = value
+			return nil
+		},
+	}
+}
+
+// TLSField picks how FTP connections are secured, ftps addresses always use
+// implicit TLS.
+var TLSField = Field{
+	Key:     "tls",
+	Label:   "TLS",
+	Kind:    ChoiceField,
+	Choices: []string{"none", ExplicitTLS, ImplicitTLS},
+	Get: func(b Bookmark) string {
+		if b.TLSMode == NoTLS {
+			return "none"
+		}
+		return b.TLSMode
+	},
+	Set: func(b *Bookmark, value string) error {
+		if value == "none" {
+			value = NoTLS
+		}
+
+		b.TLSMode = value
+		if strings.HasPrefix(b.Address, "ftps://") {
+			b.TLSMode = ImplicitTLS
+		}
+
+		return nil
+	},
+}
+
+// SplitArgs splits arguments the way a shell would, so values with spaces
+// can be quoted or escaped.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			// Inside double quotes only quotes and backslashes are escaped.
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0 && r == quote:
+			quote = 0
+
+		case quote != 0:
+			arg.WriteRune(r)
+
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unclosed %c quote", quote)
+	}
+	if escaped {
+		arg.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+// JoinArgs quotes arguments so SplitArgs returns them unchanged.
+func JoinArgs(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		if len(arg) > 0 && strings.IndexFunc(arg, needsQuote) < 0 {
+			quoted[i] = arg
+			continue
+		}
+
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func needsQuote(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`'"\`, r)
+}
+
+func boolIndex(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,39 @@
+package bookmark
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"--fs  --volume=50", []string{"--fs", "--volume=50"}},
+		{`--sub-font="Noto Sans" --title='My show'`, []string{"--sub-font=Noto Sans", "--title=My show"}},
+		{`--title=My\ show ''`, []string{"--title=My show", ""}},
+		{`"a \"quoted\" C:\path" 'it'\''s'`, []string{`a "quoted" C:\path`, "it's"}},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		got, err := SplitArgs(test.value)
+		if err != nil {
+			t.Errorf("SplitArgs(%s): %s", test.value, err)
+			continue
+		}
+
+		if strings.Join(got, "\x00") != strings.Join(test.want, "\x00") || len(got) != len(test.want) {
+			t.Errorf("SplitArgs(%s) = %q, want %q", test.value, got, test.want)
+		}
+
+		if again, _ := SplitArgs(JoinArgs(got)); strings.Join(again, "\x00") != strings.Join(got, "\x00") || len(again) != len(got) {
+			t.Errorf("JoinArgs(%q) = %s, which splits to %q", got, JoinArgs(got), again)
+		}
+	}
+
+	if _, err := SplitArgs(`--title="My show`); err == nil {
+		t.Error("unclosed quote was accepted")
+	}
+}
@@ -0,0 +1,110 @@
+package fileSource
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+type Backend struct {
+	bookmark    bookmark.Bookmark
+	currentPath []string
+}
+
+func NewFileSource(bookmark bookmark.Bookmark, path []string) *Backend {
+	return &Backend{bookmark, path}
+}
+
+func (b Backend) OpenFile(filePath string) (*player.Media, error) {
+	return player.NewMedia(filepath.Join(b.dirPath(), filePath), filePath), nil
+}
+
+func (b Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	file, err := os.Open(filepath.Join(b.dirPath(), filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return sourceFile.WithSize(file, info.Size()), nil
+}
+
+func (b *Backend) ChangeDir(dir string) {
+	if dir == ".." {
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
+	} else {
+		b.currentPath = append(b.currentPath, dir)
+	}
+}
+
+// ListDir lists dir inside the current directory without entering it.
+func (b Backend) ListDir(dir string) ([]list.Item, error) {
+	b.currentPath = append(append([]string{}, b.currentPath...), dir)
+	return b.GetItems()
+}
+
+func (b Backend) GetItems() ([]list.Item, error) {
+	dirPath := b.dirPath()
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	if dirPath != "/" {
+		listItems = append(listItems, sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."})
+	}
+
+	for _, entry := range entries {
+		item := sourceItem.Item{
+			ListingType: "file",
+			Name:        entry.Name(),
+			Path:        entry.Name(),
+		}
+
+		// Stat follows symlinks so linked directories can be entered.
+		info, err := os.Stat(filepath.Join(dirPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			item.ListingType = "dir"
+			item.Name += "/"
+		}
+
+		listItems = append(listItems, item)
+	}
+
+	return listItems, nil
+}
+
+func (b Backend) GetPathString() string {
+	return strings.Join(b.currentPath, "/")
+}
+
+func (b Backend) GetAddressString() string {
+	return b.bookmark.Address
+}
+
+func (b Backend) Close() error {
+	return nil
+}
+
+func (b Backend) dirPath() string {
+	return filepath.Clean("/" + filepath.FromSlash(b.GetPathString()))
+}
@@ -0,0 +1,367 @@
+package ftpSource
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/loopback"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"github.com/jlaffaye/ftp"
+)
+
+type Backend struct {
+	bookmark    bookmark.Bookmark
+	currentPath []string
+	conn        *ftp.ServerConn
+	mu          sync.Mutex
+}
+
+// Fields are the bookmark settings FTP servers use.
+func Fields() []bookmark.Field {
+	return []bookmark.Field{
+		bookmark.UsernameField("Username"),
+		bookmark.PasswordField("Password"),
+		bookmark.TLSField,
+	}
+}
+
+func NewFTPSource(bookmark bookmark.Bookmark, path []string) *Backend {
+	return &Backend{bookmark: bookmark, currentPath: path}
+}
+
+// OpenFile streams the file to the player through a loopback HTTP server,
+// since players cannot be relied on to speak FTPS.
+func (b *Backend) OpenFile(filePath string) (*player.Media, error) {
+	file, err := b.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	name := path.Base(filePath)
+	handler := loopback.FileHandler(name, time.Time{}, func() (io.ReadSeekCloser, error) {
+		return b.ReadFile(filePath)
+	})
+
+	server, err := loopback.Serve(handler)
+	if err != nil {
+		return nil, err
+	}
+
+	media := player.NewMedia(server.URL(name), filePath)
+	media.AddCloser(server)
+
+	return media, nil
+}
+
+func (b *Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	remotePath := path.Join(b.dirPath(), filePath)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conn, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := conn.FileSize(remotePath)
+	if err != nil {
+		b.disconnect()
+		return nil, err
+	}
+
+	return &fileReader{backend: b, path: remotePath, size: size}, nil
+}
+
+func (b *Backend) ChangeDir(dir string) {
+	if dir == ".." {
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
+	} else {
+		b.currentPath = append(b.currentPath, dir)
+	}
+}
+
+func (b *Backend) GetItems() ([]list.Item, error) {
+	return b.listDir(b.dirPath())
+}
+
+// ListDir lists dir inside the current directory without entering it.
+func (b *Backend) ListDir(dir string) ([]list.Item, error) {
+	return b.listDir(path.Join(b.dirPath(), dir))
+}
+
+func (b *Backend) listDir(dirPath string) ([]list.Item, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	conn, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	// List uses MLSD when the server advertises it and falls back to LIST.
+	entries, err := conn.List(dirPath)
+	if err != nil {
+		b.disconnect()
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	listItems := []list.Item{}
+	if dirPath != "/" {
+		listItems = append(listItems, sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."})
+	}
+
+	for _, entry := range entries {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+
+		item := sourceItem.Item{
+			ListingType: "file",
+			Name:        entry.Name,
+			Path:        entry.Name,
+			ModTime:     entry.Time,
+		}
+
+		switch entry.Type {
+		case ftp.EntryTypeFolder:
+			item.ListingType = "dir"
+			item.Name += "/"
+
+		case ftp.EntryTypeLink:
+			// Listings do not say what a link points to, MLST does when the
+			// server supports it.
+			target, err := conn.GetEntry(path.Join(dirPath, entry.Name))
+			if err == nil && target.Type == ftp.EntryTypeFolder {
+				item.ListingType = "dir"
+				item.Name += "/"
+			}
+
+		default:
+			item.Size = int64(entry.Size)
+		}
+
+		listItems = append(listItems, item)
+	}
+
+	return listItems, nil
+}
+
+func (b *Backend) GetPathString() string {
+	return strings.Join(b.currentPath, "/")
+}
+
+func (b *Backend) GetAddressString() string {
+	return b.bookmark.Address
+}
+
+// Close quits the cached control connection, file reads close their own.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.disconnect()
+	return nil
+}
+
+func (b *Backend) dirPath() string {
+	return path.Clean("/" + b.GetPathString())
+}
+
+// connect returns the cached control connection, the caller must hold mu.
+func (b *Backend) connect() (*ftp.ServerConn, error) {
+	if b.conn != nil {
+		return b.conn, nil
+	}
+
+	conn, err := b.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	b.conn = conn
+	return conn, nil
+}
+
+// disconnect drops the cached connection after an error, the caller must
+// hold mu.
+func (b *Backend) disconnect() {
+	if b.conn != nil {
+		b.conn.Quit()
+		b.conn = nil
+	}
+}
+
+// dial opens and logs in a new connection. FTP only allows one transfer per
+// connection, so file reads dial their own.
+func (b *Backend) dial() (*ftp.ServerConn, error) {
+	address, err := url.Parse(b.bookmark.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{ServerName: address.Hostname()}
+	options := []ftp.DialOption{ftp.DialWithTimeout(10 * time.Second)}
+
+	port := "21"
+	switch b.bookmark.TLSMode {
+	case bookmark.ImplicitTLS:
+		port = "990"
+		options = append(options, ftp.DialWithTLS(tlsConfig))
+
+	case bookmark.ExplicitTLS:
+		options = append(options, ftp.DialWithExplicitTLS(tlsConfig))
+	}
+
+	host := address.Host
+	if len(address.Port()) <= 0 {
+		host = net.JoinHostPort(address.Hostname(), port)
+	}
+
+	conn, err := ftp.Dial(host, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	username := b.bookmark.Username
+	password := b.bookmark.Password
+	if len(username) <= 0 {
+		username = "anonymous"
+		password = "anonymous"
+	}
+
+	err = conn.Login(username, password)
+	if err != nil {
+		conn.Quit()
+
+		var replyErr *textproto.Error
+		if errors.As(err, &replyErr) && replyErr.Code == ftp.StatusNotLoggedIn {
+			return nil, sourceFile.AuthError{Err: err}
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// fileReader seeks by restarting the transfer at the new offset.
+type fileReader struct {
+	backend  *Backend
+	path     string
+	size     int64
+	offset   int64
+	conn     *ftp.ServerConn
+	response *ftp.Response
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.response == nil {
+		if r.conn == nil {
+			conn, err := r.backend.dial()
+			if err != nil {
+				return 0, err
+			}
+			r.conn = conn
+		}
+
+		response, err := r.conn.RetrFrom(r.path, uint64(r.offset))
+		if err != nil {
+			return 0, err
+		}
+		r.response = response
+	}
+
+	n, err := r.response.Read(p)
+	r.offset += int64(n)
+
+	return n, err
+}
+
+// ReadAt transfers from a connection of its own, so it does not disturb
+// sequential reads.
+func (r *fileReader) ReadAt(p []byte, off int64) (int, error) {
+	conn, err := r.backend.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Quit()
+
+	response, err := conn.RetrFrom(r.path, uint64(off))
+	if err != nil {
+		return 0, err
+	}
+	defer response.Close()
+
+	n, err := io.ReadFull(response, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (r *fileReader) Size() int64 {
+	return r.size
+}
+
+func (r *fileReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+
+	case io.SeekEnd:
+		offset += r.size
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative seek offset")
+	}
+
+	// An aborted transfer can leave the connection in an unknown state, so
+	// start over with a new one.
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+
+	return offset, nil
+}
+
+func (r *fileReader) Close() error {
+	if r.response != nil {
+		r.response.Close()
+		r.response = nil
+	}
+
+	if r.conn != nil {
+		err := r.conn.Quit()
+		r.conn = nil
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package httpSource
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"golang.org/x/net/html"
+)
+
+// parseApacheList reads mod_autoindex output, which is a table when
+// FancyIndexing uses HTMLTable, a <pre> block for plain FancyIndexing and a
+// <ul> otherwise.
+func parseApacheList(root *html.Node) ([]list.Item, error) {
+	for _, tag := range []string{"table", "pre", "ul"} {
+		listingNode := findElement(root, tag)
+		if listingNode != nil {
+			return collectLinks(listingNode), nil
+		}
+	}
+
+	return nil, errors.New("unable to find the autoindex HTML node")
+}
+
+// hasSortLinks reports whether the page has mod_autoindex's column sorting
+// links.
+func hasSortLinks(node *html.Node) bool {
+	if node.Type == html.ElementNode && node.Data == "a" {
+		for _, attr := range node.Attr {
+			if attr.Key == "href" && strings.HasPrefix(attr.Val, "?C=") {
+				return true
+			}
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if hasSortLinks(child) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package httpSource
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"golang.org/x/net/html"
+)
+
+func detectCaddyList(root *html.Node) bool {
+	_, err := getCaddyListingNode(root)
+	return err == nil
+}
+
+func parseCaddyList(root *html.Node) ([]list.Item, error) {
+	listingNode, err := getCaddyListingNode(root)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	for row := listingNode.FirstChild; row != nil; row = row.NextSibling {
+		item, err := extractCaddyListing(row)
+		if err != nil {
+			continue
+		} else {
+			listItems = append(listItems, item)
+		}
+	}
+	return listItems, nil
+}
+
+func getCaddyListingNode(node *html.Node) (*html.Node, error) {
+	if node.Type == html.ElementNode && node.Data == "tbody" {
+		if node.Parent != nil && node.Parent.Parent != nil {
+			if isCaddyListing(node.Parent.Parent) {
+				return node, nil
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		listingNode, err := getCaddyListingNode(child)
+		if err == nil {
+			return listingNode, nil
+		}
+	}
+	return nil, errors.New("unable to find the listing table HTML node")
+}
+
+// isCaddyListing matches the element around the listing table, Caddy 1 gives
+// it the id listing and Caddy 2 the class.
+func isCaddyListing(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		switch attr.Key {
+		case "id":
+			if attr.Val == "listing" {
+				return true
+			}
+
+		case "class":
+			for _, class := range strings.Fields(attr.Val) {
+				if class == "listing" {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func extractCaddyListing(node *html.Node) (sourceItem.Item, error) {
+	item := sourceItem.Item{}
+	for col := node.FirstChild; col != nil; col = col.NextSibling {
+		for child := col.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "a" {
+				for _, attr := range child.Attr {
+					if attr.Key == "href" {
+						item.Path = attr.Val
+					}
+				}
+
+				for linkChild := child.FirstChild; linkChild != nil; linkChild = linkChild.NextSibling {
+					if linkChild.Type == html.ElementNode && linkChild.Data == "span" {
+						item.Name = linkChild.FirstChild.Data
+					}
+				}
+
+				if strings.HasSuffix(item.Path, "/") || item.Path == ".." {
+					item.ListingType = "dir"
+				} else {
+					item.ListingType = "file"
+				}
+
+				if item.Path != ".." {
+					item.Path = strings.Replace(item.Path, "/", "", -1)
+					item.Path = strings.TrimPrefix(item.Path, ".")
+					cleanPath, err := url.PathUnescape(item.Path)
+					if err != nil {
+						return item, err
+					}
+					item.Path = cleanPath
+				}
+
+				return item, nil
+			}
+		}
+
+	}
+
+	return item, errors.New("no listitem could be extracted")
+}
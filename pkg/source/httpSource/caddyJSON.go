@@ -0,0 +1,68 @@
+package httpSource
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+// caddyJSONEntry is a file in the listing Caddy's file_server browse returns
+// when asked for application/json.
+type caddyJSONEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	IsDir   bool      `json:"is_dir"`
+}
+
+type caddyJSONParser struct{}
+
+func (caddyJSONParser) Detect(r *Response) bool {
+	if r.MediaType != "application/json" {
+		return false
+	}
+
+	var entries []map[string]json.RawMessage
+	err := json.Unmarshal(r.Body, &entries)
+	if err != nil || len(entries) <= 0 {
+		return false
+	}
+
+	_, ok := entries[0]["is_dir"]
+	return ok
+}
+
+func (caddyJSONParser) Parse(r *Response) ([]list.Item, error) {
+	var entries []caddyJSONEntry
+
+	err := json.Unmarshal(r.Body, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	for _, entry := range entries {
+		item := sourceItem.Item{
+			ListingType: "file",
+			Name:        entry.Name,
+			Path:        entry.Name,
+			Size:        entry.Size,
+			ModTime:     entry.ModTime,
+		}
+
+		if entry.IsDir {
+			item.ListingType = "dir"
+			item.Path = strings.TrimSuffix(entry.Name, "/")
+			if !strings.HasSuffix(item.Name, "/") {
+				item.Name += "/"
+			}
+		}
+
+		listItems = append(listItems, item)
+	}
+
+	return listItems, nil
+}
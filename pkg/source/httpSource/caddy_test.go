@@ -0,0 +1,32 @@
+package httpSource
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDetectCaddyListingContainer(t *testing.T) {
+	tests := []struct {
+		container string
+		want      bool
+	}{
+		{`<main id="listing">`, true},
+		{`<div class="listing wide">`, true},
+		{`<div data-view="listing">`, false},
+		{`<div class="listings">`, false},
+	}
+
+	for _, test := range tests {
+		page := test.container + `<table><tbody><tr><td><a href="a.mkv">a.mkv</a></td></tr></tbody></table></div>`
+		root, err := html.Parse(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := detectCaddyList(root); got != test.want {
+			t.Errorf("detectCaddyList(%s) = %v, want %v", test.container, got, test.want)
+		}
+	}
+}
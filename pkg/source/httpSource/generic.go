@@ -0,0 +1,60 @@
+package httpSource
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"golang.org/x/net/html"
+)
+
+// detectGenericList accepts any page, the generic parser is the fallback when
+// nothing else recognises the markup.
+func detectGenericList(root *html.Node) bool {
+	return true
+}
+
+// parseGenericList takes every relative link on the page, which covers most
+// "Index of" pages such as lighttpd's or python's http.server.
+func parseGenericList(root *html.Node) ([]list.Item, error) {
+	return collectLinks(root), nil
+}
+
+func collectLinks(root *html.Node) []list.Item {
+	listItems := []list.Item{}
+	seen := map[string]bool{}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "a" {
+			item, ok := linkItem(node)
+			if ok && !seen[item.Path] {
+				seen[item.Path] = true
+				listItems = append(listItems, item)
+			}
+			return
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return listItems
+}
+
+func linkItem(node *html.Node) (sourceItem.Item, bool) {
+	// Apache links its parent with an absolute path.
+	if strings.TrimSpace(nodeText(node)) == "Parent Directory" {
+		return itemFromHref("..")
+	}
+
+	for _, attr := range node.Attr {
+		if attr.Key == "href" {
+			return itemFromHref(attr.Val)
+		}
+	}
+
+	return sourceItem.Item{}, false
+}
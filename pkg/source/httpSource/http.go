@@ -1,17 +1,16 @@
 package httpSource
 
 import (
-	"errors"
-	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"os/exec"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
 	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
 	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
-	"golang.org/x/net/html"
 )
 
 type Backend struct {
@@ -23,32 +22,78 @@ func NewHTTPSource(bookmark bookmark.Bookmark, path []string) *Backend {
 	return &Backend{bookmark, path}
 }
 
-func (b Backend) OpenFile(filePath string) error {
+// Fields are the bookmark settings HTTP servers use.
+func Fields() []bookmark.Field {
+	return []bookmark.Field{
+		bookmark.UsernameField("Username"),
+		bookmark.PasswordField("Password"),
+		{
+			Key:     "listingFormat",
+			Label:   "Listing format",
+			Kind:    bookmark.ChoiceField,
+			Choices: ListingFormats(),
+			Get:     func(b bookmark.Bookmark) string { return b.ListingFormat },
+			Set: func(b *bookmark.Bookmark, value string) error {
+				b.ListingFormat = value
+				return nil
+			},
+		},
+	}
+}
+
+func (b Backend) OpenFile(filePath string) (*player.Media, error) {
 	address, err := url.Parse(b.bookmark.Address)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	address.User = url.UserPassword(b.bookmark.Username, b.bookmark.Password)
 	address.Path = b.GetPathString() + "/" + filePath
-	runCMD := exec.Command(b.bookmark.FileViewer, address.String())
 
-	err = runCMD.Run()
+	media := player.NewMedia(address.String(), filePath)
+	media.Username = b.bookmark.Username
+	media.Password = b.bookmark.Password
+
+	return media, nil
+}
+
+func (b Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	address, err := url.Parse(b.bookmark.Address)
 	if err != nil {
-		return fmt.Errorf("%s: %s", runCMD.String(), err.Error())
+		return nil, err
 	}
 
-	return nil
+	address.Path = b.GetPathString() + "/" + filePath
+
+	return sourceFile.OpenHTTP(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", address.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(b.bookmark.Username) > 0 {
+			req.SetBasicAuth(b.bookmark.Username, b.bookmark.Password)
+		}
+
+		return req, nil
+	})
 }
 
 func (b *Backend) ChangeDir(dir string) {
 	if dir == ".." {
-		b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
 	} else {
 		b.currentPath = append(b.currentPath, dir)
 	}
 }
 
+// ListDir lists dir inside the current directory without entering it.
+func (b Backend) ListDir(dir string) ([]list.Item, error) {
+	b.currentPath = append(append([]string{}, b.currentPath...), dir)
+	return b.GetItems()
+}
+
 func (b Backend) GetItems() ([]list.Item, error) {
 	address, err := url.Parse(b.bookmark.Address)
 	if err != nil {
@@ -62,6 +107,8 @@ func (b Backend) GetItems() ([]list.Item, error) {
 		return nil, err
 	}
 
+	req.Header.Set("Accept", acceptHeader(b.bookmark.ListingFormat))
+
 	if len(b.bookmark.Username) > 0 {
 		req.SetBasicAuth(b.bookmark.Username, b.bookmark.Password)
 	}
@@ -73,19 +120,30 @@ func (b Backend) GetItems() ([]list.Item, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("%s: %s", req.URL, resp.Status)
+		return nil, sourceFile.StatusError{URL: req.URL.String(), Status: resp.Status, StatusCode: resp.StatusCode}
 	}
 
-	doc, err := html.Parse(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	var listItems []list.Item
 
-	listItems, err = parseCaddyList(doc)
+	listItems, err = parseListing(b.bookmark.ListingFormat, resp.Header.Get("Content-Type"), body)
 	if err != nil {
-		return nil, err
+		return nil, sourceFile.ParseError{URL: req.URL.String(), Err: err}
+	}
+
+	// Some listing formats never include a parent entry, but the file picker
+	// relies on one to go up a directory. nginx links one even at the root,
+	// where there is nowhere to go.
+	switch {
+	case len(b.GetPathString()) <= 0:
+		listItems = withoutParentItem(listItems)
+
+	case !hasParentItem(listItems):
+		listItems = append([]list.Item{sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."}}, listItems...)
 	}
 
 	return listItems, nil
@@ -99,77 +157,29 @@ func (b Backend) GetAddressString() string {
 	return b.bookmark.Address
 }
 
-func parseCaddyList(root *html.Node) ([]list.Item, error) {
-	listingNode, err := getCaddyListingNode(root)
-	if err != nil {
-		return nil, err
-	}
-
-	listItems := []list.Item{}
-	for row := listingNode.FirstChild; row != nil; row = row.NextSibling {
-		item, err := extractCaddyListing(row)
-		if err != nil {
-			continue
-		} else {
-			listItems = append(listItems, item)
-		}
-	}
-	return listItems, nil
+func (b Backend) Close() error {
+	return nil
 }
 
-func getCaddyListingNode(node *html.Node) (*html.Node, error) {
-	if node.Type == html.ElementNode && node.Data == "tbody" {
-		if node.Parent.Parent.Attr[0].Val == "listing" {
-			return node, nil
+func hasParentItem(listItems []list.Item) bool {
+	for _, listItem := range listItems {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.Path == ".." {
+			return true
 		}
 	}
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		listingNode, err := getCaddyListingNode(child)
-		if err == nil {
-			return listingNode, nil
-		}
-	}
-	return nil, errors.New("unable to find the listing table HTML node")
+
+	return false
 }
 
-func extractCaddyListing(node *html.Node) (sourceItem.Item, error) {
-	item := sourceItem.Item{}
-	for col := node.FirstChild; col != nil; col = col.NextSibling {
-		for child := col.FirstChild; child != nil; child = child.NextSibling {
-			if child.Type == html.ElementNode && child.Data == "a" {
-				for _, attr := range child.Attr {
-					if attr.Key == "href" {
-						item.Path = attr.Val
-					}
-				}
-
-				for linkChild := child.FirstChild; linkChild != nil; linkChild = linkChild.NextSibling {
-					if linkChild.Type == html.ElementNode && linkChild.Data == "span" {
-						item.Name = linkChild.FirstChild.Data
-					}
-				}
-
-				if strings.HasSuffix(item.Path, "/") || item.Path == ".." {
-					item.ListingType = "dir"
-				} else {
-					item.ListingType = "file"
-				}
-
-				if item.Path != ".." {
-					item.Path = strings.Replace(item.Path, "/", "", -1)
-					item.Path = strings.TrimPrefix(item.Path, ".")
-					cleanPath, err := url.PathUnescape(item.Path)
-					if err != nil {
-						return item, err
-					}
-					item.Path = cleanPath
-				}
-
-				return item, nil
-			}
+func withoutParentItem(listItems []list.Item) []list.Item {
+	kept := []list.Item{}
+	for _, listItem := range listItems {
+		item, ok := listItem.(sourceItem.Item)
+		if !ok || item.Path != ".." {
+			kept = append(kept, listItem)
 		}
-
 	}
 
-	return item, errors.New("no listitem could be extracted")
+	return kept
 }
@@ -0,0 +1,54 @@
+package httpSource
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+func TestNginxRootHasNoParent(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "nginx.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	b := NewHTTPSource(bookmark.Bookmark{Address: server.URL}, strings.Split("", "/"))
+
+	listItems, err := b.GetItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, listItem := range listItems {
+		if listItem.(sourceItem.Item).Path == ".." {
+			t.Fatal("the root listing has a parent entry")
+		}
+	}
+
+	// Going up from the root stays there.
+	b.ChangeDir("..")
+	b.ChangeDir("..")
+	if b.GetPathString() != "" {
+		t.Fatalf("path is %q after going up from the root", b.GetPathString())
+	}
+
+	b.ChangeDir("Season 1")
+	listItems, err = b.GetItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listItems[0].(sourceItem.Item).Path != ".." {
+		t.Fatalf("listing below the root starts with %v, want ..", listItems[0])
+	}
+}
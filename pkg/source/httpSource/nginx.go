@@ -0,0 +1,111 @@
+package httpSource
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"golang.org/x/net/html"
+)
+
+type nginxJSONEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type nginxXMLList struct {
+	Entries []struct {
+		XMLName xml.Name
+		Name    string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func detectNginxList(root *html.Node) bool {
+	title := findElement(root, "title")
+
+	return title != nil && strings.HasPrefix(nodeText(title), "Index of") && findElement(root, "pre") != nil
+}
+
+// parseNginxList reads the default autoindex format, a single <pre> block of
+// links followed by their dates and sizes.
+func parseNginxList(root *html.Node) ([]list.Item, error) {
+	preNode := findElement(root, "pre")
+	if preNode == nil {
+		return nil, errors.New("unable to find the autoindex HTML node")
+	}
+
+	listItems := []list.Item{}
+	for child := preNode.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "a" {
+			continue
+		}
+
+		for _, attr := range child.Attr {
+			if attr.Key != "href" {
+				continue
+			}
+
+			item, ok := itemFromHref(attr.Val)
+			if ok {
+				listItems = append(listItems, item)
+			}
+		}
+	}
+
+	return listItems, nil
+}
+
+type nginxJSONParser struct{}
+
+func (nginxJSONParser) Detect(r *Response) bool {
+	return r.MediaType == "application/json"
+}
+
+func (nginxJSONParser) Parse(r *Response) ([]list.Item, error) {
+	var entries []nginxJSONEntry
+
+	err := json.Unmarshal(r.Body, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	for _, entry := range entries {
+		listItems = append(listItems, newNginxItem(entry.Name, entry.Type == "directory"))
+	}
+
+	return listItems, nil
+}
+
+type nginxXMLParser struct{}
+
+func (nginxXMLParser) Detect(r *Response) bool {
+	return r.MediaType == "application/xml" || r.MediaType == "text/xml"
+}
+
+func (nginxXMLParser) Parse(r *Response) ([]list.Item, error) {
+	var entries nginxXMLList
+
+	err := xml.Unmarshal(r.Body, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	for _, entry := range entries.Entries {
+		listItems = append(listItems, newNginxItem(entry.Name, entry.XMLName.Local == "directory"))
+	}
+
+	return listItems, nil
+}
+
+func newNginxItem(name string, isDir bool) sourceItem.Item {
+	if isDir {
+		return sourceItem.Item{ListingType: "dir", Name: name + "/", Path: name}
+	}
+
+	return sourceItem.Item{ListingType: "file", Name: name, Path: name}
+}
@@ -0,0 +1,223 @@
+package httpSource
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"golang.org/x/net/html"
+)
+
+// Listing formats registered by this package. Bookmarks with an empty or auto
+// format have it detected from the response.
+const (
+	AutoFormat      = "auto"
+	CaddyFormat     = "caddy"
+	CaddyJSONFormat = "caddy-json"
+	NginxFormat     = "nginx"
+	NginxJSONFormat = "nginx-json"
+	NginxXMLFormat  = "nginx-xml"
+	ApacheFormat    = "apache"
+	GenericFormat   = "generic"
+)
+
+// ListingParser turns the response to a directory request into list items.
+type ListingParser interface {
+	// Detect reports whether a response looks like this parser's format.
+	Detect(r *Response) bool
+	Parse(r *Response) ([]list.Item, error)
+}
+
+// Response is the response to a directory request, shared by the parsers
+// tried on it so HTML is only parsed once.
+type Response struct {
+	MediaType string
+	Body      []byte
+	doc       *html.Node
+	docErr    error
+	docParsed bool
+}
+
+func newResponse(contentType string, body []byte) *Response {
+	if len(contentType) <= 0 {
+		contentType = http.DetectContentType(body)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return &Response{MediaType: mediaType, Body: body}
+}
+
+// HTML parses the body as an HTML document.
+func (r *Response) HTML() (*html.Node, error) {
+	if !r.docParsed {
+		r.doc, r.docErr = html.Parse(bytes.NewReader(r.Body))
+		r.docParsed = true
+	}
+
+	return r.doc, r.docErr
+}
+
+var (
+	parsers     = map[string]ListingParser{}
+	parserOrder []string
+)
+
+func init() {
+	RegisterParser(CaddyFormat, htmlParser{detectCaddyList, parseCaddyList})
+	RegisterParser(ApacheFormat, htmlParser{hasSortLinks, parseApacheList})
+	RegisterParser(NginxFormat, htmlParser{detectNginxList, parseNginxList})
+	RegisterParser(CaddyJSONFormat, caddyJSONParser{})
+	RegisterParser(NginxJSONFormat, nginxJSONParser{})
+	RegisterParser(NginxXMLFormat, nginxXMLParser{})
+	RegisterParser(GenericFormat, htmlParser{detectGenericList, parseGenericList})
+}
+
+// RegisterParser makes a parser available to bookmarks by its format name.
+// Auto detection tries parsers in the order they were registered, falling
+// back to the generic parser.
+func RegisterParser(format string, parser ListingParser) {
+	if _, ok := parsers[format]; !ok {
+		parserOrder = append(parserOrder, format)
+	}
+
+	parsers[format] = parser
+}
+
+// ListingFormats returns the registered format names.
+func ListingFormats() []string {
+	return append([]string{AutoFormat}, parserOrder...)
+}
+
+// acceptHeader asks servers that can send JSON listings, like Caddy, to do so
+// when the bookmark allows it.
+func acceptHeader(format string) string {
+	switch format {
+	case "", AutoFormat, CaddyJSONFormat:
+		return "application/json, text/html;q=0.9, */*;q=0.8"
+
+	default:
+		return "text/html, */*;q=0.8"
+	}
+}
+
+func parseListing(format, contentType string, body []byte) ([]list.Item, error) {
+	r := newResponse(contentType, body)
+
+	if len(format) <= 0 || format == AutoFormat {
+		format = detectFormat(r)
+	}
+
+	parser, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown listing format: %s", format)
+	}
+
+	return parser.Parse(r)
+}
+
+func detectFormat(r *Response) string {
+	for _, format := range parserOrder {
+		if format != GenericFormat && parsers[format].Detect(r) {
+			return format
+		}
+	}
+
+	return GenericFormat
+}
+
+// htmlParser adapts functions working on a parsed document to ListingParser.
+type htmlParser struct {
+	detect func(*html.Node) bool
+	parse  func(*html.Node) ([]list.Item, error)
+}
+
+func (p htmlParser) Detect(r *Response) bool {
+	if r.MediaType != "text/html" {
+		return false
+	}
+
+	doc, err := r.HTML()
+	if err != nil {
+		return false
+	}
+
+	return p.detect(doc)
+}
+
+func (p htmlParser) Parse(r *Response) ([]list.Item, error) {
+	doc, err := r.HTML()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parse(doc)
+}
+
+// itemFromHref builds an item from a relative link in a listing, or returns
+// false if the link does not point into the listed directory.
+func itemFromHref(href string) (sourceItem.Item, bool) {
+	if href == ".." || href == "../" {
+		return sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."}, true
+	}
+
+	link, err := url.Parse(href)
+	if err != nil || len(link.Scheme) > 0 || len(link.Host) > 0 || len(link.RawQuery) > 0 || len(link.Fragment) > 0 {
+		return sourceItem.Item{}, false
+	}
+
+	cleanPath := strings.TrimPrefix(link.Path, "./")
+	if len(cleanPath) <= 0 || strings.HasPrefix(cleanPath, "/") {
+		return sourceItem.Item{}, false
+	}
+
+	item := sourceItem.Item{ListingType: "file"}
+	if strings.HasSuffix(cleanPath, "/") {
+		item.ListingType = "dir"
+		cleanPath = strings.TrimSuffix(cleanPath, "/")
+	}
+
+	if strings.Contains(cleanPath, "/") {
+		return sourceItem.Item{}, false
+	}
+
+	item.Path = cleanPath
+	item.Name = cleanPath
+	if item.ListingType == "dir" {
+		item.Name += "/"
+	}
+
+	return item, true
+}
+
+func findElement(node *html.Node, tag string) *html.Node {
+	if node.Type == html.ElementNode && node.Data == tag {
+		return node
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		found := findElement(child, tag)
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func nodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var text string
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text += nodeText(child)
+	}
+
+	return text
+}
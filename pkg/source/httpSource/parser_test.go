@@ -0,0 +1,114 @@
+package httpSource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+type wantItem struct {
+	listingType string
+	name        string
+	path        string
+}
+
+var (
+	parentItem  = wantItem{"dir", "..", ".."}
+	seasonItem  = wantItem{"dir", "Season 1/", "Season 1"}
+	episodeItem = wantItem{"file", "Episode 1.mkv", "Episode 1.mkv"}
+)
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		format      string
+		want        []wantItem
+	}{
+		{"caddy.html", "text/html; charset=utf-8", CaddyFormat, []wantItem{{"dir", "Go up", ".."}, seasonItem, episodeItem}},
+		{"caddy.json", "application/json", CaddyJSONFormat, []wantItem{seasonItem, episodeItem}},
+		{"nginx.html", "text/html", NginxFormat, []wantItem{parentItem, seasonItem, episodeItem}},
+		{"nginx.json", "application/json", NginxJSONFormat, []wantItem{seasonItem, episodeItem}},
+		{"nginx.xml", "text/xml", NginxXMLFormat, []wantItem{seasonItem, episodeItem}},
+		{"apache-table.html", "text/html;charset=UTF-8", ApacheFormat, []wantItem{parentItem, seasonItem, episodeItem}},
+		{"apache-pre.html", "text/html;charset=UTF-8", ApacheFormat, []wantItem{parentItem, seasonItem, episodeItem}},
+		{"generic.html", "text/html; charset=utf-8", GenericFormat, []wantItem{seasonItem, episodeItem}},
+		// Without a content type the body is sniffed.
+		{"nginx.html", "", NginxFormat, []wantItem{parentItem, seasonItem, episodeItem}},
+	}
+
+	for _, test := range tests {
+		body, err := os.ReadFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if format := detectFormat(newResponse(test.contentType, body)); format != test.format {
+			t.Errorf("%s detected as %s, want %s", test.file, format, test.format)
+			continue
+		}
+
+		listItems, err := parseListing(AutoFormat, test.contentType, body)
+		if err != nil {
+			t.Errorf("%s: %s", test.file, err)
+			continue
+		}
+
+		got := []wantItem{}
+		for _, listItem := range listItems {
+			item := listItem.(sourceItem.Item)
+			got = append(got, wantItem{item.ListingType, item.Name, item.Path})
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("%s parsed to %v, want %v", test.file, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s parsed to %v, want %v", test.file, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFixedFormatSkipsDetection(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "nginx.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Generic takes every link, nginx's parent included.
+	listItems, err := parseListing(GenericFormat, "text/html", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listItems) != 3 {
+		t.Fatalf("generic parser found %d items, want 3", len(listItems))
+	}
+
+	_, err = parseListing("lighttpd", "text/html", body)
+	if err == nil {
+		t.Fatal("unknown format parsed without an error")
+	}
+}
+
+func TestResponseParsesHTMLOnce(t *testing.T) {
+	r := newResponse("text/html", []byte("<html><body><a href=\"a.mkv\">a.mkv</a></body></html>"))
+
+	first, err := r.HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range parserOrder {
+		parsers[format].Detect(r)
+	}
+
+	second, _ := r.HTML()
+	if first != second {
+		t.Fatal("HTML was parsed again")
+	}
+}
@@ -0,0 +1,320 @@
+package s3Source
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+// defaultURLExpiry bounds how long a URL handed to the player stays usable.
+// Players seek with new requests, so long videos may need a longer one.
+const defaultURLExpiry = time.Hour
+
+// maxURLExpiry is the longest SigV4 allows.
+const maxURLExpiry = 7 * 24 * time.Hour
+
+type listBucketsResult struct {
+	Buckets []struct {
+		Name         string    `xml:"Name"`
+		CreationDate time.Time `xml:"CreationDate"`
+	} `xml:"Buckets>Bucket"`
+}
+
+type listObjectsResult struct {
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Backend browses an S3 compatible endpoint with path style requests, buckets
+// and prefixes are listed as directories. The bookmark's username and
+// password are the access and secret keys.
+type Backend struct {
+	bookmark    bookmark.Bookmark
+	currentPath []string
+}
+
+// Fields are the bookmark settings S3 buckets use, requests are only signed
+// when there is an access key.
+func Fields() []bookmark.Field {
+	return []bookmark.Field{
+		bookmark.UsernameField("Access key"),
+		bookmark.PasswordField("Secret key"),
+		{
+			Key:         "region",
+			Label:       "Region",
+			Placeholder: "us-east-1",
+			Get:         func(b bookmark.Bookmark) string { return b.Region },
+			Set: func(b *bookmark.Bookmark, value string) error {
+				b.Region = value
+				return nil
+			},
+		},
+		{
+			Key:         "urlExpiry",
+			Label:       "URL lifetime",
+			Placeholder: "1h",
+			Get:         func(b bookmark.Bookmark) string { return b.URLExpiry },
+			Set: func(b *bookmark.Bookmark, value string) error {
+				if len(value) > 0 {
+					if _, err := parseURLExpiry(value); err != nil {
+						return err
+					}
+				}
+
+				b.URLExpiry = value
+				return nil
+			},
+		},
+	}
+}
+
+func parseURLExpiry(value string) (time.Duration, error) {
+	expiry, err := time.ParseDuration(value)
+	if err != nil || expiry <= 0 || expiry > maxURLExpiry {
+		return 0, fmt.Errorf("URL lifetime must be a duration like 30m, up to 168h")
+	}
+
+	return expiry, nil
+}
+
+func NewS3Source(bookmark bookmark.Bookmark, path []string) *Backend {
+	return &Backend{bookmark, path}
+}
+
+// OpenFile hands the player a presigned URL so the keys never appear on its
+// command line.
+func (b Backend) OpenFile(filePath string) (*player.Media, error) {
+	address, err := b.objectURL(append(b.pathParts(), filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fileURL := address.String()
+	if len(b.bookmark.Username) > 0 {
+		expiry := defaultURLExpiry
+		if len(b.bookmark.URLExpiry) > 0 {
+			expiry, err = parseURLExpiry(b.bookmark.URLExpiry)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fileURL = b.signer().presign(address, time.Now(), expiry)
+	}
+
+	return player.NewMedia(fileURL, filePath), nil
+}
+
+func (b Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	address, err := b.objectURL(append(b.pathParts(), filePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return sourceFile.OpenHTTP(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", address.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(b.bookmark.Username) > 0 {
+			b.signer().sign(req, time.Now())
+		}
+
+		return req, nil
+	})
+}
+
+func (b *Backend) ChangeDir(dir string) {
+	if dir == ".." {
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
+	} else {
+		b.currentPath = append(b.currentPath, dir)
+	}
+}
+
+// ListDir lists dir inside the current directory without entering it.
+func (b Backend) ListDir(dir string) ([]list.Item, error) {
+	b.currentPath = append(append([]string{}, b.currentPath...), dir)
+	return b.GetItems()
+}
+
+func (b Backend) GetItems() ([]list.Item, error) {
+	parts := b.pathParts()
+	if len(parts) <= 0 {
+		return b.listBuckets()
+	}
+
+	listItems := []list.Item{sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."}}
+
+	prefix := ""
+	if len(parts) > 1 {
+		prefix = strings.Join(parts[1:], "/") + "/"
+	}
+
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("delimiter", "/")
+		query.Set("prefix", prefix)
+		if len(continuationToken) > 0 {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		var result listObjectsResult
+		err := b.get(parts[:1], query, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commonPrefix := range result.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(commonPrefix.Prefix, prefix), "/")
+			listItems = append(listItems, sourceItem.Item{ListingType: "dir", Name: name + "/", Path: name})
+		}
+
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, prefix)
+			if len(name) <= 0 {
+				continue
+			}
+
+			listItems = append(listItems, sourceItem.Item{
+				ListingType: "file",
+				Name:        name,
+				Path:        name,
+				Size:        object.Size,
+				ModTime:     object.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || len(result.NextContinuationToken) <= 0 {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return listItems, nil
+}
+
+func (b Backend) GetPathString() string {
+	return strings.Join(b.currentPath, "/")
+}
+
+func (b Backend) GetAddressString() string {
+	return b.bookmark.Address
+}
+
+func (b Backend) Close() error {
+	return nil
+}
+
+func (b Backend) listBuckets() ([]list.Item, error) {
+	var result listBucketsResult
+	err := b.get(nil, url.Values{}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := []list.Item{}
+	for _, bucket := range result.Buckets {
+		listItems = append(listItems, sourceItem.Item{
+			ListingType: "dir",
+			Name:        bucket.Name + "/",
+			Path:        bucket.Name,
+			ModTime:     bucket.CreationDate,
+		})
+	}
+
+	return listItems, nil
+}
+
+func (b Backend) get(parts []string, query url.Values, result interface{}) error {
+	address, err := b.objectURL(parts, query)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", address.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	if len(b.bookmark.Username) > 0 {
+		b.signer().sign(req, time.Now())
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return sourceFile.StatusError{URL: req.URL.Redacted(), Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+
+	err = xml.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return sourceFile.ParseError{URL: req.URL.Redacted(), Err: err}
+	}
+
+	return nil
+}
+
+// objectURL escapes the path and query exactly as they are signed.
+func (b Backend) objectURL(parts []string, query url.Values) (*url.URL, error) {
+	address, err := b.bookmark.TransportURL()
+	if err != nil {
+		return nil, err
+	}
+
+	address.Path = "/" + strings.Join(parts, "/")
+	address.RawPath = uriEncode(address.Path, false)
+	address.RawQuery = canonicalQuery(query)
+
+	return address, nil
+}
+
+func (b Backend) pathParts() []string {
+	parts := []string{}
+	for _, part := range b.currentPath {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
+
+func (b Backend) signer() signer {
+	region := b.bookmark.Region
+	if len(region) <= 0 {
+		region = "us-east-1"
+	}
+
+	return signer{
+		accessKey: b.bookmark.Username,
+		secretKey: b.bookmark.Password,
+		region:    region,
+	}
+}
@@ -0,0 +1,427 @@
+package sftpSource
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/loopback"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// dialTimeout bounds both the TCP connect and the SSH handshake.
+var dialTimeout = 10 * time.Second
+
+type Backend struct {
+	bookmark    bookmark.Bookmark
+	currentPath []string
+	session     *session
+	mu          sync.Mutex
+}
+
+// session is an SSH connection and its SFTP client. Listings and open files
+// each hold a reference, so dropping the session after an error does not cut
+// off a file that is still playing.
+type session struct {
+	client *sftp.Client
+	conn   *ssh.Client
+	refs   int
+}
+
+func (s *session) close() {
+	s.client.Close()
+	s.conn.Close()
+}
+
+// sessionFile releases its session's reference once closed.
+type sessionFile struct {
+	*sftp.File
+	release func()
+	once    sync.Once
+}
+
+func (f *sessionFile) Close() error {
+	err := f.File.Close()
+	f.once.Do(f.release)
+	return err
+}
+
+// Fields are the bookmark settings SFTP servers use, the password unlocks the
+// key file when there is one.
+func Fields() []bookmark.Field {
+	return []bookmark.Field{
+		bookmark.UsernameField("Username"),
+		bookmark.PasswordField("Password/passphrase"),
+		{
+			Key:         "keyFile",
+			Label:       "Key file",
+			Placeholder: "~/.ssh/id_ed25519",
+			Get:         func(b bookmark.Bookmark) string { return b.KeyFile },
+			Set: func(b *bookmark.Bookmark, value string) error {
+				b.KeyFile = value
+				return nil
+			},
+		},
+	}
+}
+
+func NewSFTPSource(bookmark bookmark.Bookmark, path []string) *Backend {
+	return &Backend{bookmark: bookmark, currentPath: path}
+}
+
+// OpenFile streams the file to the player through a loopback HTTP server, as
+// not every mpv build can read sftp:// URLs with credentials.
+func (b *Backend) OpenFile(filePath string) (*player.Media, error) {
+	s, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	remotePath := path.Join(b.dirPath(), filePath)
+
+	info, err := s.client.Stat(remotePath)
+	if err != nil {
+		b.disconnect()
+	}
+	b.release(s)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := loopback.FileHandler(info.Name(), info.ModTime(), func() (io.ReadSeekCloser, error) {
+		return b.open(remotePath)
+	})
+
+	server, err := loopback.Serve(handler)
+	if err != nil {
+		return nil, err
+	}
+
+	media := player.NewMedia(server.URL(info.Name()), filePath)
+	media.AddCloser(server)
+
+	return media, nil
+}
+
+func (b *Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	return b.open(path.Join(b.dirPath(), filePath))
+}
+
+// open keeps the session open until the returned file is closed, whatever
+// happens to the backend meanwhile.
+func (b *Backend) open(remotePath string) (sourceFile.File, error) {
+	s, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.client.Open(remotePath)
+	if err != nil {
+		b.disconnect()
+		b.release(s)
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		b.release(s)
+		return nil, err
+	}
+
+	return sourceFile.WithSize(&sessionFile{File: file, release: func() { b.release(s) }}, info.Size()), nil
+}
+
+func (b *Backend) ChangeDir(dir string) {
+	if dir == ".." {
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
+	} else {
+		b.currentPath = append(b.currentPath, dir)
+	}
+}
+
+func (b *Backend) GetItems() ([]list.Item, error) {
+	return b.listDir(b.dirPath())
+}
+
+// ListDir lists dir inside the current directory without entering it.
+func (b *Backend) ListDir(dir string) ([]list.Item, error) {
+	return b.listDir(path.Join(b.dirPath(), dir))
+}
+
+func (b *Backend) listDir(dirPath string) ([]list.Item, error) {
+	s, err := b.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer b.release(s)
+
+	entries, err := s.client.ReadDir(dirPath)
+	if err != nil {
+		b.disconnect()
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	listItems := []list.Item{}
+	if dirPath != "/" {
+		listItems = append(listItems, sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."})
+	}
+
+	for _, info := range entries {
+		item := sourceItem.Item{
+			ListingType: "file",
+			Name:        info.Name(),
+			Path:        info.Name(),
+			ModTime:     info.ModTime(),
+		}
+
+		// Stat follows symlinks so linked directories can be entered.
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkInfo, err := s.client.Stat(path.Join(dirPath, info.Name()))
+			if err != nil {
+				continue
+			}
+			info = linkInfo
+		}
+
+		if info.IsDir() {
+			item.ListingType = "dir"
+			item.Name += "/"
+		} else {
+			item.Size = info.Size()
+		}
+
+		listItems = append(listItems, item)
+	}
+
+	return listItems, nil
+}
+
+func (b *Backend) GetPathString() string {
+	return strings.Join(b.currentPath, "/")
+}
+
+func (b *Backend) GetAddressString() string {
+	return b.bookmark.Address
+}
+
+func (b *Backend) dirPath() string {
+	return path.Clean("/" + b.GetPathString())
+}
+
+// connect returns the cached session, dialling one if there is none. The
+// caller holds a reference until it calls release.
+func (b *Backend) connect() (*session, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session == nil {
+		s, err := b.dial()
+		if err != nil {
+			return nil, err
+		}
+		b.session = s
+	}
+
+	b.session.refs++
+	return b.session, nil
+}
+
+func (b *Backend) release(s *session) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s.refs--
+	if s.refs <= 0 && s != b.session {
+		s.close()
+	}
+}
+
+func (b *Backend) dial() (*session, error) {
+	address, err := url.Parse(b.bookmark.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	host := address.Host
+	if len(address.Port()) <= 0 {
+		host = net.JoinHostPort(address.Hostname(), "22")
+	}
+
+	hostKeyCallback, err := knownHostsCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	// The agent signs during the handshake, so its connection is only needed
+	// until it is done.
+	var agentClient agent.Agent
+	if socket := os.Getenv("SSH_AUTH_SOCK"); len(socket) > 0 {
+		agentConn, err := net.Dial("unix", socket)
+		if err == nil {
+			defer agentConn.Close()
+			agentClient = agent.NewClient(agentConn)
+		}
+	}
+
+	// Authentication follows the host key check, so a handshake failing
+	// after it means the server refused the credentials.
+	hostVerified := false
+	config := &ssh.ClientConfig{
+		User: b.bookmark.Username,
+		Auth: b.authMethods(agentClient),
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			err := hostKeyCallback(hostname, remote, key)
+			hostVerified = err == nil
+			return err
+		},
+	}
+
+	tcpConn, err := net.DialTimeout("tcp", host, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// ClientConfig.Timeout only covers the TCP connect, a server that
+	// accepts and then stays silent would hang the handshake.
+	tcpConn.SetDeadline(time.Now().Add(dialTimeout))
+
+	sshConn, channels, requests, err := ssh.NewClientConn(tcpConn, host, config)
+	if err != nil {
+		tcpConn.Close()
+		if hostVerified {
+			return nil, sourceFile.AuthError{Err: err}
+		}
+		return nil, err
+	}
+
+	conn := ssh.NewClient(sshConn, channels, requests)
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	tcpConn.SetDeadline(time.Time{})
+
+	return &session{client: client, conn: conn}, nil
+}
+
+// Close ends the SSH session once its open files are closed, a later request
+// reconnects.
+func (b *Backend) Close() error {
+	b.disconnect()
+	return nil
+}
+
+// disconnect drops the cached session after an error, so the next request
+// reconnects instead of reusing a dead one. Files still open keep it alive
+// until they are closed.
+func (b *Backend) disconnect() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session != nil {
+		if b.session.refs <= 0 {
+			b.session.close()
+		}
+		b.session = nil
+	}
+}
+
+// authMethods offers the key file and agent keys through one callback, as
+// each method name is only tried once.
+func (b *Backend) authMethods(agentClient agent.Agent) []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+
+	if len(b.bookmark.KeyFile) > 0 || agentClient != nil {
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			var signers []ssh.Signer
+			var keyErr error
+
+			if len(b.bookmark.KeyFile) > 0 {
+				signer, err := b.keyFileSigner()
+				if err == nil {
+					signers = append(signers, signer)
+				}
+				keyErr = err
+			}
+
+			if agentClient != nil {
+				agentSigners, err := agentClient.Signers()
+				if err == nil {
+					signers = append(signers, agentSigners...)
+				}
+			}
+
+			if len(signers) <= 0 && keyErr != nil {
+				return nil, keyErr
+			}
+
+			return signers, nil
+		}))
+	}
+
+	if len(b.bookmark.Password) > 0 {
+		methods = append(methods, ssh.Password(b.bookmark.Password))
+	}
+
+	return methods
+}
+
+func (b *Backend) keyFileSigner() (ssh.Signer, error) {
+	keyBytes, err := os.ReadFile(expandHome(b.bookmark.KeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.bookmark.Password) > 0 {
+		return ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(b.bookmark.Password))
+	}
+
+	return ssh.ParsePrivateKey(keyBytes)
+}
+
+func knownHostsCallback() (ssh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(expandHome("~/.ssh/known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read known_hosts, connect once with ssh to add the server: %s", err)
+	}
+
+	return callback, nil
+}
+
+func expandHome(filePath string) string {
+	if !strings.HasPrefix(filePath, "~/") {
+		return filePath
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filePath
+	}
+
+	return filepath.Join(home, filePath[2:])
+}
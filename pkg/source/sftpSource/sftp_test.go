@@ -0,0 +1,363 @@
+package sftpSource
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshServer serves the local filesystem over SFTP to clients offering key.
+type sshServer struct {
+	listener net.Listener
+	hostKey  ssh.Signer
+	key      ssh.PublicKey
+	closed   chan struct{}
+}
+
+func newSSHServer(t *testing.T, key ssh.PublicKey) *sshServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &sshServer{
+		listener: listener,
+		hostKey:  newSigner(t),
+		key:      key,
+		closed:   make(chan struct{}, 1),
+	}
+	go s.serve()
+
+	return s
+}
+
+func (s *sshServer) serve() {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), s.key.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(s.hostKey)
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(requests)
+
+			for newChannel := range channels {
+				channel, channelRequests, err := newChannel.Accept()
+				if err != nil {
+					continue
+				}
+
+				go func() {
+					for request := range channelRequests {
+						request.Reply(request.Type == "subsystem", nil)
+						if request.Type == "subsystem" {
+							server, err := sftp.NewServer(channel)
+							if err == nil {
+								server.Serve()
+							}
+							channel.Close()
+						}
+					}
+				}()
+			}
+
+			serverConn.Wait()
+			s.closed <- struct{}{}
+		}()
+	}
+}
+
+// knownHosts writes a known_hosts trusting the server to a new HOME.
+func (s *sshServer) knownHosts(t *testing.T) {
+	writeKnownHosts(t, knownhosts.Line([]string{knownhosts.Normalize(s.listener.Addr().String())}, s.hostKey.PublicKey())+"\n")
+}
+
+func writeKnownHosts(t *testing.T, content string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	err := os.Mkdir(filepath.Join(home, ".ssh"), 0700)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(content), 0600)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	signer, err := ssh.NewSignerFromKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func writeKeyFile(t *testing.T, key ed25519.PrivateKey) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyFile
+}
+
+// serveAgent points SSH_AUTH_SOCK at an agent holding key.
+func serveAgent(t *testing.T, key ed25519.PrivateKey) {
+	keyring := agent.NewKeyring()
+	err := keyring.Add(agent.AddedKey{PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+}
+
+func newBackend(t *testing.T, server *sshServer, keyFile string, dir string) *Backend {
+	b := bookmark.Bookmark{
+		Backend:  bookmark.SFTP,
+		Address:  "sftp://" + server.listener.Addr().String(),
+		Username: "user",
+		KeyFile:  keyFile,
+	}
+
+	return NewSFTPSource(b, strings.Split(strings.TrimPrefix(filepath.ToSlash(dir), "/"), "/"))
+}
+
+func TestListsAndReadsWithKeyFile(t *testing.T) {
+	key := newKey(t)
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newSSHServer(t, signer.PublicKey())
+	server.knownHosts(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "episode.mkv"), []byte("video"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBackend(t, server, writeKeyFile(t, key), dir)
+	items, err := b.GetItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 2 || items[1].(sourceItem.Item).Name != "episode.mkv" {
+		t.Fatalf("listed %v, want .. and episode.mkv", items)
+	}
+
+	file, err := b.ReadFile("episode.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(content) != "video" {
+		t.Fatalf("read %q, %v, want video", content, err)
+	}
+
+	b.Close()
+	<-server.closed
+}
+
+func TestOffersAgentKeysAlongsideKeyFile(t *testing.T) {
+	agentKey := newKey(t)
+	agentSigner, err := ssh.NewSignerFromKey(agentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newSSHServer(t, agentSigner.PublicKey())
+	server.knownHosts(t)
+	serveAgent(t, agentKey)
+
+	// The server does not accept the key file, only the agent's key.
+	b := newBackend(t, server, writeKeyFile(t, newKey(t)), t.TempDir())
+	_, err = b.GetItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.Close()
+	<-server.closed
+}
+
+func TestRejectedKeyIsAuthError(t *testing.T) {
+	server := newSSHServer(t, newSigner(t).PublicKey())
+	server.knownHosts(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	b := newBackend(t, server, writeKeyFile(t, newKey(t)), t.TempDir())
+	_, err := b.GetItems()
+
+	var authErr sourceFile.AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("got %v, want an AuthError", err)
+	}
+}
+
+func TestUnknownHostIsNotAuthError(t *testing.T) {
+	server := newSSHServer(t, newSigner(t).PublicKey())
+	writeKnownHosts(t, "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	b := newBackend(t, server, writeKeyFile(t, newKey(t)), t.TempDir())
+	_, err := b.GetItems()
+
+	var authErr sourceFile.AuthError
+	if err == nil || errors.As(err, &authErr) {
+		t.Fatalf("got %v, want a host key error", err)
+	}
+}
+
+func TestOpenFileOutlivesListingError(t *testing.T) {
+	key := newKey(t)
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newSSHServer(t, signer.PublicKey())
+	server.knownHosts(t)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "episode.mkv"), []byte("video"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBackend(t, server, writeKeyFile(t, key), dir)
+	file, err := b.ReadFile("episode.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed listing drops the session, the open file keeps it alive.
+	_, err = b.ListDir("missing")
+	if err == nil {
+		t.Fatal("listed a missing directory")
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil || string(content) != "video" {
+		t.Fatalf("read %q, %v, want video", content, err)
+	}
+
+	select {
+	case <-server.closed:
+		t.Fatal("session closed while a file was open")
+	default:
+	}
+
+	file.Close()
+	<-server.closed
+}
+
+func TestHandshakeTimesOut(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Accept connections and never answer.
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	writeKnownHosts(t, "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	timeout := dialTimeout
+	dialTimeout = 100 * time.Millisecond
+	defer func() { dialTimeout = timeout }()
+
+	b := NewSFTPSource(bookmark.Bookmark{
+		Backend: bookmark.SFTP,
+		Address: "sftp://" + listener.Addr().String(),
+	}, nil)
+
+	start := time.Now()
+	_, err = b.GetItems()
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("got %v after %s, want a timeout", err, time.Since(start))
+	}
+}
@@ -4,25 +4,115 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
 	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/fileSource"
+	"github.com/ibrokemypie/kwatch/pkg/source/ftpSource"
 	"github.com/ibrokemypie/kwatch/pkg/source/httpSource"
+	"github.com/ibrokemypie/kwatch/pkg/source/s3Source"
+	"github.com/ibrokemypie/kwatch/pkg/source/sftpSource"
+	"github.com/ibrokemypie/kwatch/pkg/source/webdavSource"
 )
 
 type Source interface {
-	OpenFile(filePath string) error
+	OpenFile(filePath string) (*player.Media, error)
 	GetItems() ([]list.Item, error)
+	// ListDir lists a directory inside the current one without entering it.
+	ListDir(dir string) ([]list.Item, error)
 	ChangeDir(dir string)
 	GetPathString() string
 	GetAddressString() string
+	// Close drops any connection the source holds open.
+	Close() error
 }
 
 func NewSource(b bookmark.Bookmark) Source {
+	backend := newBackend(b)
+	if backend == nil {
+		return nil
+	}
+
+	return &subtitleSource{Source: &archiveSource{Source: backend}}
+}
+
+// NewSourceAt opens b in dir, a path from GetPathString, entering any
+// archive along it. Directories named like archives are found out and
+// entered as directories once listed.
+func NewSourceAt(b bookmark.Bookmark, dir string) Source {
+	parts := strings.Split(strings.Trim(dir, "/"), "/")
+
+	for i, part := range parts {
+		if isArchive(part) {
+			b.Path = "/" + strings.Join(parts[:i], "/")
+
+			s := NewSource(b)
+			if s != nil {
+				for _, part := range parts[i:] {
+					s.ChangeDir(part)
+				}
+			}
+
+			return s
+		}
+	}
+
+	b.Path = "/" + strings.Join(parts, "/")
+	return NewSource(b)
+}
+
+// Fields lists the settings the editor shows for a backend, its own
+// between the location and the player settings.
+func Fields(backend bookmark.BackendType) []bookmark.Field {
+	var backendFields []bookmark.Field
+
+	switch backend {
+	case bookmark.HTTP:
+		backendFields = httpSource.Fields()
+
+	case bookmark.SFTP:
+		backendFields = sftpSource.Fields()
+
+	case bookmark.FTP:
+		backendFields = ftpSource.Fields()
+
+	case bookmark.WebDAV:
+		backendFields = webdavSource.Fields()
+
+	case bookmark.S3:
+		backendFields = s3Source.Fields()
+	}
+
+	var fields []bookmark.Field
+	fields = append(fields, bookmark.LocationFields...)
+	fields = append(fields, backendFields...)
+	fields = append(fields, bookmark.PlayerFields...)
+	fields = append(fields, bookmark.GroupFields...)
+
+	return fields
+}
+
+func newBackend(b bookmark.Bookmark) Source {
 	path := strings.Split(strings.TrimPrefix(b.Path, "/"), "/")
 
 	switch b.Backend {
 	case bookmark.HTTP:
 		return httpSource.NewHTTPSource(b, path)
 
+	case bookmark.File:
+		return fileSource.NewFileSource(b, path)
+
+	case bookmark.SFTP:
+		return sftpSource.NewSFTPSource(b, path)
+
+	case bookmark.FTP:
+		return ftpSource.NewFTPSource(b, path)
+
+	case bookmark.WebDAV:
+		return webdavSource.NewWebDAVSource(b, path)
+
+	case bookmark.S3:
+		return s3Source.NewS3Source(b, path)
+
 	default:
 		return nil
 	}
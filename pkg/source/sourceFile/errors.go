@@ -0,0 +1,36 @@
+package sourceFile
+
+// StatusError is a server refusing a request, kept apart from other errors so
+// auth failures can be told from missing paths.
+type StatusError struct {
+	URL        string
+	Status     string
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return e.URL + ": " + e.Status
+}
+
+// ParseError is a server response that could not be read as a listing.
+type ParseError struct {
+	URL string
+	Err error
+}
+
+func (e ParseError) Error() string {
+	return e.URL + ": " + e.Err.Error()
+}
+
+// AuthError is a server rejecting the bookmark's credentials.
+type AuthError struct {
+	Err error
+}
+
+func (e AuthError) Error() string {
+	return e.Err.Error()
+}
+
+func (e AuthError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,41 @@
+package sourceFile
+
+import (
+	"io"
+)
+
+// File is an open file from a source that can be read from any offset, used
+// to look inside archives without fetching them whole.
+type File interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	Size() int64
+}
+
+// Reader is implemented by sources that can read file contents themselves,
+// rather than only handing a location to the player.
+type Reader interface {
+	ReadFile(filePath string) (File, error)
+}
+
+type sizedFile struct {
+	file
+	size int64
+}
+
+type file interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// WithSize adapts files that already read at offsets, like an *os.File.
+func WithSize(f interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}, size int64) File {
+	return sizedFile{f, size}
+}
+
+func (f sizedFile) Size() int64 {
+	return f.size
+}
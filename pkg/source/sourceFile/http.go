@@ -0,0 +1,136 @@
+package sourceFile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type httpFile struct {
+	newRequest func() (*http.Request, error)
+	size       int64
+	offset     int64
+	body       io.ReadCloser
+}
+
+// OpenHTTP reads a file with range requests, newRequest builds an authorised
+// GET request for it.
+func OpenHTTP(newRequest func() (*http.Request, error)) (File, error) {
+	f := &httpFile{newRequest: newRequest}
+
+	resp, err := f.get("bytes=0-0")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	contentRange := resp.Header.Get("Content-Range")
+	size, err := strconv.ParseInt(contentRange[strings.LastIndex(contentRange, "/")+1:], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid Content-Range %q", resp.Request.URL.Redacted(), contentRange)
+	}
+
+	f.size = size
+	return f, nil
+}
+
+func (f *httpFile) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+
+	if f.body == nil {
+		resp, err := f.get(fmt.Sprintf("bytes=%d-", f.offset))
+		if err != nil {
+			return 0, err
+		}
+		f.body = resp.Body
+	}
+
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+
+	return n, err
+}
+
+func (f *httpFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p)) - 1
+	if end >= f.size {
+		end = f.size - 1
+	}
+
+	resp, err := f.get(fmt.Sprintf("bytes=%d-%d", off, end))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	n, err := io.ReadFull(resp.Body, p[:end-off+1])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (f *httpFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+
+	case io.SeekEnd:
+		offset += f.size
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative seek offset")
+	}
+
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *httpFile) Close() error {
+	if f.body != nil {
+		return f.body.Close()
+	}
+
+	return nil
+}
+
+func (f *httpFile) Size() int64 {
+	return f.size
+}
+
+func (f *httpFile) get(byteRange string) (*http.Response, error) {
+	req, err := f.newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Range", byteRange)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: range request failed: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	return resp, nil
+}
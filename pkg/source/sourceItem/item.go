@@ -1,13 +1,20 @@
 package sourceItem
 
 import (
+	"fmt"
 	"strings"
+	"time"
 )
 
 type Item struct {
 	ListingType string
 	Name        string
 	Path        string
+	Size        int64
+	ModTime     time.Time
+	// Watched and Progress come from the playback state, not the source.
+	Watched  bool
+	Progress float64
 }
 
 func (i Item) Title() string {
@@ -15,9 +22,40 @@ func (i Item) Title() string {
 }
 
 func (i Item) Description() string {
-	return strings.ToTitle(i.ListingType)
+	description := strings.ToTitle(i.ListingType)
+
+	if i.Watched {
+		description += "  WATCHED"
+	} else if i.Progress > 0 {
+		description += fmt.Sprintf("  %d%%", int(i.Progress*100))
+	}
+
+	if i.ListingType == "file" && i.Size > 0 {
+		description += "  " + formatSize(i.Size)
+	}
+
+	if !i.ModTime.IsZero() {
+		description += "  " + i.ModTime.Local().Format("2006-01-02 15:04")
+	}
+
+	return description
 }
 
 func (i Item) FilterValue() string {
 	return i.Name
 }
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,47 @@
+package sourceItem
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NaturalLess orders names the way episodes are numbered, runs of digits
+// compare by value so "Episode 9" comes before "Episode 10".
+func NaturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+
+	for len(a) > 0 && len(b) > 0 {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if len(aDigits) > 0 && len(bDigits) > 0 {
+			aNumber, bNumber := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+			if len(aNumber) != len(bNumber) {
+				return len(aNumber) < len(bNumber)
+			}
+			if aNumber != bNumber {
+				return aNumber < bNumber
+			}
+
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+
+		aRune, aSize := utf8.DecodeRuneInString(a)
+		bRune, bSize := utf8.DecodeRuneInString(b)
+		if aRune != bRune {
+			return aRune < bRune
+		}
+
+		a, b = a[aSize:], b[bSize:]
+	}
+
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return s[:i]
+}
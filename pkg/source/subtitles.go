@@ -0,0 +1,208 @@
+package source
+
+import (
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+var subtitleExtensions = map[string]bool{
+	".srt": true,
+	".ass": true,
+	".ssa": true,
+	".vtt": true,
+	".sub": true,
+}
+
+// subtitleDirs are the folders release groups put subtitles in, either
+// directly or in a folder per episode.
+var subtitleDirs = []string{"subs", "subtitles"}
+
+// subtitleSource hands players the subtitles found next to a video, which
+// they would only load by themselves for local files. Files are opened off
+// the UI goroutine, so subtitles are looked up without moving the source.
+type subtitleSource struct {
+	Source
+	mu sync.Mutex
+	// listings are the directories listed since the last ChangeDir, by their
+	// path from the current directory. generation tells listings made before
+	// a ChangeDir from those made after.
+	listings   map[string][]list.Item
+	generation int
+}
+
+func (s *subtitleSource) ChangeDir(dir string) {
+	s.mu.Lock()
+	s.listings = nil
+	s.generation++
+	s.mu.Unlock()
+
+	s.Source.ChangeDir(dir)
+}
+
+// GetItems keeps the listing the file picker shows, so opening a file does not
+// list its directory again.
+func (s *subtitleSource) GetItems() ([]list.Item, error) {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	items, err := s.Source.GetItems()
+	if err == nil {
+		s.keepListing(generation, "", items)
+	}
+
+	return items, err
+}
+
+func (s *subtitleSource) OpenFile(filePath string) (*player.Media, error) {
+	media, err := s.Source.OpenFile(filePath)
+	if err != nil || !player.IsMedia(filePath) {
+		return media, err
+	}
+
+	// Subtitles are a nice to have, the video plays without them.
+	items, err := s.listDir("")
+	if err != nil {
+		return media, nil
+	}
+
+	stem := strings.TrimSuffix(filePath, path.Ext(filePath))
+	s.addSubtitles(media, "", items, func(name string) bool {
+		return matchesVideo(name, stem)
+	})
+
+	for _, item := range listItems(items) {
+		if item.ListingType != "dir" || !isSubtitleDir(item.Path) {
+			continue
+		}
+
+		subItems, err := s.listDir(item.Path)
+		if err != nil {
+			continue
+		}
+
+		s.addSubtitles(media, item.Path, subItems, func(name string) bool {
+			return matchesVideo(name, stem)
+		})
+
+		s.addEpisodeDir(media, item.Path, subItems, stem)
+	}
+
+	return media, nil
+}
+
+// addEpisodeDir adds every subtitle in a folder named after the video, like
+// Subs/Episode 1/English.srt.
+func (s *subtitleSource) addEpisodeDir(media *player.Media, dir string, items []list.Item, stem string) {
+	for _, item := range listItems(items) {
+		if item.ListingType != "dir" || !strings.EqualFold(item.Path, stem) {
+			continue
+		}
+
+		episodeDir := path.Join(dir, item.Path)
+		episodeItems, err := s.listDir(episodeDir)
+		if err == nil {
+			s.addSubtitles(media, episodeDir, episodeItems, func(string) bool {
+				return true
+			})
+		}
+	}
+}
+
+func (s *subtitleSource) addSubtitles(media *player.Media, dir string, items []list.Item, match func(name string) bool) {
+	for _, item := range listItems(items) {
+		if item.ListingType != "file" || !isSubtitle(item.Path) || !match(item.Path) {
+			continue
+		}
+
+		subtitle, err := s.Source.OpenFile(path.Join(dir, item.Path))
+		if err != nil {
+			continue
+		}
+
+		media.Subtitles = append(media.Subtitles, subtitle.URL)
+		media.AddCloser(subtitle)
+	}
+}
+
+// listDir lists dir from the current directory, reusing listings already
+// made so a playlist only lists each directory once.
+func (s *subtitleSource) listDir(dir string) ([]list.Item, error) {
+	s.mu.Lock()
+	items, ok := s.listings[dir]
+	generation := s.generation
+	s.mu.Unlock()
+
+	if ok {
+		return items, nil
+	}
+
+	var err error
+	if len(dir) <= 0 {
+		items, err = s.Source.GetItems()
+	} else {
+		items, err = s.Source.ListDir(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.keepListing(generation, dir, items), nil
+}
+
+// keepListing stores a copy of a listing, the file picker changes the items
+// it is handed.
+func (s *subtitleSource) keepListing(generation int, dir string, items []list.Item) []list.Item {
+	items = append([]list.Item{}, items...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return items
+	}
+
+	if s.listings == nil {
+		s.listings = map[string][]list.Item{}
+	}
+	s.listings[dir] = items
+
+	return items
+}
+
+func listItems(items []list.Item) []sourceItem.Item {
+	var sourceItems []sourceItem.Item
+	for _, listItem := range items {
+		if item, ok := listItem.(sourceItem.Item); ok && item.Path != ".." {
+			sourceItems = append(sourceItems, item)
+		}
+	}
+
+	return sourceItems
+}
+
+func isSubtitle(name string) bool {
+	return subtitleExtensions[strings.ToLower(path.Ext(name))]
+}
+
+func isSubtitleDir(name string) bool {
+	for _, dir := range subtitleDirs {
+		if strings.EqualFold(name, dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesVideo accepts subtitles named after the video, with an optional
+// language or other tag like "Episode 1.en.srt".
+func matchesVideo(name, stem string) bool {
+	name, stem = strings.ToLower(name), strings.ToLower(stem)
+	return strings.HasPrefix(name, stem+".")
+}
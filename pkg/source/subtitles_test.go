@@ -0,0 +1,69 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSubtitlesFoundWithoutMovingSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"Episode 1.mkv",
+		"Episode 1.en.srt",
+		"Episode 10.srt",
+		"Subs/Episode 1.ass",
+		"Subs/Episode 1/English.vtt",
+		"Subs/Episode 2/English.vtt",
+	)
+
+	s := NewSource(bookmark.Bookmark{Backend: bookmark.File, Address: "file://", Path: dir})
+	before := s.GetPathString()
+
+	if _, err := s.GetItems(); err != nil {
+		t.Fatal(err)
+	}
+
+	media, err := s.OpenFile("Episode 1.mkv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer media.Close()
+
+	got := media.Subtitles
+	sort.Strings(got)
+	want := []string{
+		filepath.Join(dir, "Episode 1.en.srt"),
+		filepath.Join(dir, "Subs", "Episode 1", "English.vtt"),
+		filepath.Join(dir, "Subs", "Episode 1.ass"),
+	}
+	sort.Strings(want)
+
+	if len(got) != len(want) {
+		t.Fatalf("subtitles are %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("subtitles are %q, want %q", got, want)
+		}
+	}
+
+	if s.GetPathString() != before {
+		t.Fatalf("path moved from %q to %q", before, s.GetPathString())
+	}
+}
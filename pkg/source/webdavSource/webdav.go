@@ -0,0 +1,222 @@
+package webdavSource
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
+)
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+	<d:prop>
+		<d:resourcetype/>
+		<d:getcontentlength/>
+		<d:getlastmodified/>
+	</d:prop>
+</d:propfind>`
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+type Backend struct {
+	bookmark    bookmark.Bookmark
+	currentPath []string
+}
+
+// Fields are the bookmark settings WebDAV servers use.
+func Fields() []bookmark.Field {
+	return []bookmark.Field{
+		bookmark.UsernameField("Username"),
+		bookmark.PasswordField("Password"),
+	}
+}
+
+func NewWebDAVSource(bookmark bookmark.Bookmark, path []string) *Backend {
+	return &Backend{bookmark, path}
+}
+
+func (b Backend) OpenFile(filePath string) (*player.Media, error) {
+	address, err := b.bookmark.TransportURL()
+	if err != nil {
+		return nil, err
+	}
+
+	address.Path = b.GetPathString() + "/" + filePath
+
+	media := player.NewMedia(address.String(), filePath)
+	media.Username = b.bookmark.Username
+	media.Password = b.bookmark.Password
+
+	return media, nil
+}
+
+func (b Backend) ReadFile(filePath string) (sourceFile.File, error) {
+	address, err := b.bookmark.TransportURL()
+	if err != nil {
+		return nil, err
+	}
+
+	address.Path = b.GetPathString() + "/" + filePath
+
+	return sourceFile.OpenHTTP(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", address.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(b.bookmark.Username) > 0 {
+			req.SetBasicAuth(b.bookmark.Username, b.bookmark.Password)
+		}
+
+		return req, nil
+	})
+}
+
+func (b *Backend) ChangeDir(dir string) {
+	if dir == ".." {
+		if len(b.currentPath) > 0 {
+			b.currentPath = b.currentPath[:len(b.currentPath)-1]
+		}
+	} else {
+		b.currentPath = append(b.currentPath, dir)
+	}
+}
+
+// ListDir lists dir inside the current directory without entering it.
+func (b Backend) ListDir(dir string) ([]list.Item, error) {
+	b.currentPath = append(append([]string{}, b.currentPath...), dir)
+	return b.GetItems()
+}
+
+func (b Backend) GetItems() ([]list.Item, error) {
+	address, err := b.bookmark.TransportURL()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collections are requested with a trailing slash to avoid redirects.
+	address.Path = path.Clean("/" + b.GetPathString())
+	if address.Path != "/" {
+		address.Path += "/"
+	}
+
+	req, err := http.NewRequest("PROPFIND", address.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	if len(b.bookmark.Username) > 0 {
+		req.SetBasicAuth(b.bookmark.Username, b.bookmark.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, sourceFile.StatusError{URL: req.URL.String(), Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+
+	var status multistatus
+	err = xml.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return nil, sourceFile.ParseError{URL: req.URL.String(), Err: err}
+	}
+
+	items := []sourceItem.Item{}
+	for _, response := range status.Responses {
+		href, err := url.Parse(response.Href)
+		if err != nil {
+			continue
+		}
+
+		// Depth 1 includes the collection itself.
+		if strings.TrimSuffix(href.Path, "/") == strings.TrimSuffix(address.Path, "/") {
+			continue
+		}
+
+		name := path.Base(href.Path)
+		item := sourceItem.Item{ListingType: "file", Name: name, Path: name}
+
+		for _, propstat := range response.Propstat {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+
+			prop := propstat.Prop
+			if prop.ResourceType.Collection != nil {
+				item.ListingType = "dir"
+			}
+
+			if size, err := strconv.ParseInt(prop.ContentLength, 10, 64); err == nil {
+				item.Size = size
+			}
+
+			if modTime, err := http.ParseTime(prop.LastModified); err == nil {
+				item.ModTime = modTime
+			}
+		}
+
+		if item.ListingType == "dir" {
+			item.Name += "/"
+			item.Size = 0
+		}
+
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	listItems := []list.Item{}
+	if len(b.GetPathString()) > 0 {
+		listItems = append(listItems, sourceItem.Item{ListingType: "dir", Name: "..", Path: ".."})
+	}
+
+	for _, item := range items {
+		listItems = append(listItems, item)
+	}
+
+	return listItems, nil
+}
+
+func (b Backend) GetPathString() string {
+	return strings.Join(b.currentPath, "/")
+}
+
+func (b Backend) GetAddressString() string {
+	return b.bookmark.Address
+}
+
+func (b Backend) Close() error {
+	return nil
+}
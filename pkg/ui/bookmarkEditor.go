@@ -1,20 +1,27 @@
 package ui
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"net/url"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/source"
 	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
 )
 
 type bookmarkEditorKeymap struct {
 	NextField   key.Binding
 	PrevField   key.Binding
+	NextChoice  key.Binding
+	PrevChoice  key.Binding
 	Select      key.Binding
 	LeaveEditor key.Binding
 }
@@ -25,6 +32,7 @@ var (
 	cursorStyle        = focusedStyle.Copy()
 	focusedButtonStyle = focusedStyle.Copy().Padding(0, 1)
 	blurredButtonStyle = blurredStyle.Copy().Padding(0, 1)
+	invalidStyle       = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"})
 	titleBarStyle      = lipgloss.NewStyle().Padding(0, 0, 1, 2)
 	titleStyle         = lipgloss.NewStyle().
 				Background(lipgloss.Color("62")).
@@ -33,21 +41,37 @@ var (
 )
 
 type bookmarkEditorModel struct {
-	config        *cfg.Config
-	bookmarkIndex int
-	createNew     bool
-	inputs        []textinput.Model
-	inputCount    int
-	focusIndex    int
-	width         int
-	height        int
-	keys          bookmarkEditorKeymap
+	config     *cfg.Config
+	bookmarkID string
+	createNew  bool
+	// bookmark is the one being edited, settings without a field in the
+	// form keep their values.
+	bookmark bookmark.Bookmark
+	backend  bookmark.BackendType
+	fields   []bookmark.Field
+	// fieldErrors are shown next to fields as they are filled in.
+	fieldErrors []error
+	// testStatus is the result of the last connection test, testToken tells
+	// it from tests started before the form changed.
+	testStatus string
+	testFailed bool
+	testToken  int
+	inputs     []textinput.Model
+	inputCount int
+	focusIndex int
+	width      int
+	height     int
+	keys       bookmarkEditorKeymap
 }
 
 func (m bookmarkEditorModel) ShortHelp() []key.Binding {
 	bindings := []key.Binding{}
 
-	bindings = append(bindings, m.keys.Select, m.keys.NextField, m.keys.PrevField, m.keys.LeaveEditor)
+	bindings = append(bindings, m.keys.Select, m.keys.NextField, m.keys.PrevField)
+	if m.focusedChoice() {
+		bindings = append(bindings, m.keys.NextChoice)
+	}
+	bindings = append(bindings, m.keys.LeaveEditor)
 
 	return bindings
 }
@@ -80,6 +104,11 @@ func (m bookmarkEditorModel) Init() tea.Cmd {
 func (m *bookmarkEditorModel) updateInputs(msg tea.Msg) tea.Cmd {
 	var cmds = make([]tea.Cmd, len(m.inputs))
 
+	// Choices are only changed by cycling through them.
+	if _, ok := msg.(tea.KeyMsg); ok && m.focusedChoice() {
+		return nil
+	}
+
 	// Only text inputs with Focus() set will respond, so it's safe to simply
 	// update all of them here without any further logic.
 	for i := range m.inputs {
@@ -89,17 +118,34 @@ func (m *bookmarkEditorModel) updateInputs(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-func (m bookmarkEditorModel) saveBookmark() tea.Cmd {
-	addressURL, err := url.Parse(m.inputs[0].Value())
-	if err != nil {
-		return errorCmd(err)
+// formBookmark applies the form to the bookmark being edited.
+func (m bookmarkEditorModel) formBookmark() (bookmark.Bookmark, error) {
+	b := m.bookmark
+
+	shown := map[string]bool{}
+	for i, field := range m.fields {
+		err := field.Set(&b, m.inputs[i].Value())
+		if err != nil {
+			return b, err
+		}
+		shown[field.Key] = true
 	}
 
-	if len(addressURL.Scheme) <= 0 {
-		return errorCmd(fmt.Errorf("Address requires scheme (http/https)"))
+	// Settings of the bookmark's old backend that this one does not show
+	// would otherwise be kept where they cannot be seen.
+	if m.backend != m.bookmark.Backend {
+		for _, field := range source.Fields(m.bookmark.Backend) {
+			if !shown[field.Key] {
+				field.Set(&b, "")
+			}
+		}
 	}
 
-	newBookmark, err := bookmark.NewBookmark(addressURL, m.inputs[1].Value(), m.inputs[2].Value(), m.inputs[3].Value())
+	return b, nil
+}
+
+func (m bookmarkEditorModel) saveBookmark() tea.Cmd {
+	newBookmark, err := m.formBookmark()
 	if err != nil {
 		return errorCmd(err)
 	}
@@ -107,12 +153,188 @@ func (m bookmarkEditorModel) saveBookmark() tea.Cmd {
 	if m.createNew {
 		m.config.AddBookmark(newBookmark)
 	} else {
-		m.config.UpdateBookmark(m.bookmarkIndex, newBookmark)
+		index := m.config.FindBookmarkID(m.bookmarkID)
+		if index < 0 {
+			return errorCmd(fmt.Errorf("Bookmark no longer exists"))
+		}
+
+		m.config.UpdateBookmark(index, newBookmark)
 	}
 
 	return saveBookmarkCmd
 }
 
+// setFields lays the form out for b's backend, keeping the values already
+// entered.
+func (m *bookmarkEditorModel) setFields(b bookmark.Bookmark) {
+	values := map[string]string{}
+	for i, field := range m.fields {
+		values[field.Key] = m.inputs[i].Value()
+	}
+
+	m.backend = b.Backend
+	m.fields = source.Fields(b.Backend)
+	m.inputs = make([]textinput.Model, len(m.fields))
+	m.inputCount = len(m.fields) + 2
+
+	for i, field := range m.fields {
+		t := textinput.NewModel()
+		t.CursorStyle = cursorStyle
+		t.CharLimit = 256
+		t.Prompt = field.Label + ": "
+		t.Placeholder = field.Placeholder
+
+		if field.Kind == bookmark.SecretField {
+			t.EchoMode = textinput.EchoPassword
+			t.EchoCharacter = '*'
+		}
+
+		value, ok := values[field.Key]
+		if !ok {
+			value = field.Get(b)
+		}
+		if field.Kind == bookmark.ChoiceField {
+			value = field.Choices[choiceIndex(field, value)]
+		}
+		t.SetValue(value)
+
+		m.inputs[i] = t
+	}
+}
+
+// validate checks the fields that have been filled in.
+func (m *bookmarkEditorModel) validate() {
+	b := m.bookmark
+	m.fieldErrors = make([]error, len(m.fields))
+
+	for i, field := range m.fields {
+		value := m.inputs[i].Value()
+
+		err := field.Set(&b, value)
+		if err != nil && len(value) > 0 {
+			m.fieldErrors[i] = err
+		}
+	}
+}
+
+const connectionTestTimeout = 20 * time.Second
+
+// testConnection lists the bookmark's path using the values in the form,
+// which do not have to be saved.
+func (m *bookmarkEditorModel) testConnection() tea.Cmd {
+	m.testToken++
+	m.testFailed = false
+
+	b, err := m.formBookmark()
+	if err != nil {
+		m.testStatus = err.Error()
+		m.testFailed = true
+		return nil
+	}
+
+	m.testStatus = "Connecting..."
+	token := m.testToken
+
+	return func() tea.Msg {
+		s := source.NewSource(b)
+		if s == nil {
+			return connectionTestMsg{token: token, err: fmt.Errorf("Unsupported backend")}
+		}
+
+		// Listings cannot be cancelled, so a slow one is left to finish and
+		// close the source in the background.
+		done := make(chan connectionTestMsg, 1)
+		go func() {
+			items, err := s.GetItems()
+			s.Close()
+			done <- connectionTestMsg{token: token, items: len(items), err: err}
+		}()
+
+		select {
+		case msg := <-done:
+			return msg
+		case <-time.After(connectionTestTimeout):
+			return connectionTestMsg{token: token, err: fmt.Errorf("Timed out after %s", connectionTestTimeout)}
+		}
+	}
+}
+
+// describeConnectionError says what went wrong in a connection test.
+func describeConnectionError(err error) string {
+	var statusErr sourceFile.StatusError
+	var parseErr sourceFile.ParseError
+	var authErr sourceFile.AuthError
+
+	switch {
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == 401 || statusErr.StatusCode == 403):
+		return "Authentication failed: " + statusErr.Status
+
+	case errors.As(err, &statusErr):
+		return "Server responded " + statusErr.Status
+
+	case errors.As(err, &parseErr):
+		return "Could not read the listing, try another listing format: " + parseErr.Err.Error()
+
+	case errors.As(err, &authErr):
+		return "Authentication failed: " + authErr.Error()
+
+	case isTLSError(err):
+		return "TLS error: " + err.Error()
+
+	default:
+		return "Connection failed: " + err.Error()
+	}
+}
+
+func isTLSError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &recordHeader)
+}
+
+// updateBackend changes the form's fields once the address picks another
+// backend.
+func (m *bookmarkEditorModel) updateBackend() tea.Cmd {
+	if m.focusIndex >= len(m.fields) || m.fields[m.focusIndex].Key != "address" {
+		return nil
+	}
+
+	b := m.bookmark
+	if b.SetAddress(m.inputs[m.focusIndex].Value()) != nil || b.Backend == m.backend {
+		return nil
+	}
+
+	m.setFields(b)
+	return m.updateInputStyles()
+}
+
+func (m bookmarkEditorModel) focusedChoice() bool {
+	return m.focusIndex < len(m.fields) && m.fields[m.focusIndex].Kind == bookmark.ChoiceField
+}
+
+// cycleChoice moves the focused choice field by step through its choices.
+func (m *bookmarkEditorModel) cycleChoice(step int) {
+	field := m.fields[m.focusIndex]
+	index := choiceIndex(field, m.inputs[m.focusIndex].Value()) + step
+
+	index = (index + len(field.Choices)) % len(field.Choices)
+	m.inputs[m.focusIndex].SetValue(field.Choices[index])
+}
+
+func choiceIndex(field bookmark.Field, value string) int {
+	for i, choice := range field.Choices {
+		if choice == value {
+			return i
+		}
+	}
+
+	return 0
+}
+
 func (m *bookmarkEditorModel) updateInputStyles() tea.Cmd {
 	cmds := make([]tea.Cmd, len(m.inputs))
 	for i := 0; i <= len(m.inputs)-1; i++ {
@@ -132,12 +354,6 @@ func (m *bookmarkEditorModel) updateInputStyles() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-func (m *bookmarkEditorModel) clearInputs() {
-	for i := range m.inputs {
-		m.inputs[i].Reset()
-	}
-}
-
 func (m bookmarkEditorModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
@@ -150,30 +366,54 @@ func (m bookmarkEditorModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 
 	case newBookmarkMsg:
 		m.createNew = true
+		m.bookmark = bookmark.Bookmark{}
+		m.fields = nil
+		m.setFields(m.bookmark)
+		m.testStatus = ""
+
 		m.focusIndex = 0
-		m.clearInputs()
 		cmds = append(cmds, m.updateInputStyles())
 
 	case editBookmarkMsg:
-		m.bookmarkIndex = msg.bookmarkIndex
-		m.createNew = false
-		m.clearInputs()
-
-		bookmark := m.config.GetBookmark(m.bookmarkIndex)
+		index := m.config.FindBookmarkID(msg.bookmarkID)
+		if index < 0 {
+			cmds = append(cmds, openBookmarkPickerCmd)
+			break
+		}
 
-		m.inputs[0].SetValue(bookmark.Address)
-		m.inputs[1].SetValue(bookmark.Path)
-		m.inputs[2].SetValue(bookmark.Username)
-		m.inputs[3].SetValue(bookmark.Password)
+		m.bookmarkID = msg.bookmarkID
+		m.createNew = false
+		m.bookmark = m.config.GetBookmark(index)
+		m.fields = nil
+		m.setFields(m.bookmark)
+		m.testStatus = ""
 
 		m.focusIndex = 0
 		cmds = append(cmds, m.updateInputStyles())
 
+	case connectionTestMsg:
+		if msg.token != m.testToken {
+			break
+		}
+
+		if msg.err != nil {
+			m.testStatus = describeConnectionError(msg.err)
+			m.testFailed = true
+		} else {
+			m.testStatus = fmt.Sprintf("Connected, %d items listed", msg.items)
+		}
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.LeaveEditor):
 			cmds = append(cmds, openBookmarkPickerCmd)
 
+		case m.focusedChoice() && key.Matches(msg, m.keys.NextChoice):
+			m.cycleChoice(1)
+
+		case m.focusedChoice() && key.Matches(msg, m.keys.PrevChoice):
+			m.cycleChoice(-1)
+
 		case key.Matches(msg, m.keys.NextField):
 			m.focusIndex++
 
@@ -190,9 +430,12 @@ func (m bookmarkEditorModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.Select):
 			switch m.focusIndex {
-			case m.inputCount - 1:
+			case len(m.inputs):
 				cmds = append(cmds, m.saveBookmark())
 
+			case len(m.inputs) + 1:
+				cmds = append(cmds, m.testConnection())
+
 			case m.inputCount:
 				cmds = append(cmds, openBookmarkPickerCmd)
 
@@ -208,7 +451,9 @@ func (m bookmarkEditorModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 	}
 
 	cmd = m.updateInputs(msg)
-	cmds = append(cmds, cmd)
+	cmds = append(cmds, cmd, m.updateBackend())
+	m.validate()
+
 	return &m, tea.Batch(cmds...)
 }
 
@@ -216,8 +461,21 @@ func (m bookmarkEditorModel) inputsView() string {
 	var sections = make([]string, len(m.inputs))
 
 	for i := range m.inputs {
-		inputView := lipgloss.NewStyle().Padding(0, 0, 0, 2).Render(m.inputs[i].View())
-		sections[i] = inputView
+		view := m.inputs[i].View()
+		if m.fields[i].Kind == bookmark.ChoiceField {
+			style := blurredStyle
+			if i == m.focusIndex {
+				style = focusedStyle
+			}
+
+			view = style.Render(fmt.Sprintf("%s< %s >", m.inputs[i].Prompt, m.inputs[i].Value()))
+		}
+
+		if i < len(m.fieldErrors) && m.fieldErrors[i] != nil {
+			view += "  " + invalidStyle.Render(m.fieldErrors[i].Error())
+		}
+
+		sections[i] = lipgloss.NewStyle().Padding(0, 0, 0, 2).Render(view)
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
@@ -226,10 +484,10 @@ func (m bookmarkEditorModel) inputsView() string {
 func (m bookmarkEditorModel) titleView() string {
 	var title string
 
-	if m.createNew {
-		title = "New Bookmark"
+	if index := m.config.FindBookmarkID(m.bookmarkID); !m.createNew && index >= 0 {
+		title = m.config.GetBookmark(index).Title()
 	} else {
-		title = m.config.GetBookmark(m.bookmarkIndex).Title()
+		title = "New Bookmark"
 	}
 
 	return titleBarStyle.Render(titleStyle.Render(title))
@@ -246,30 +504,36 @@ func (m bookmarkEditorModel) View() string {
 	sections = append(sections, titleView)
 	availHeight -= lipgloss.Height(titleView)
 
-	var submitView string
-	if m.focusIndex == m.inputCount-1 {
-		submitView = focusedButtonStyle.Render("Submit")
-	} else {
-		submitView = blurredButtonStyle.Render("Submit")
-	}
-	buttons = append(buttons, submitView)
-
-	var cancelView string
-	if m.focusIndex == m.inputCount {
-		cancelView = focusedButtonStyle.Render("Cancel")
-	} else {
-		cancelView = blurredButtonStyle.Render("Cancel")
+	for i, label := range []string{"Submit", "Test connection", "Cancel"} {
+		if m.focusIndex == len(m.inputs)+i {
+			buttons = append(buttons, focusedButtonStyle.Render(label))
+		} else {
+			buttons = append(buttons, blurredButtonStyle.Render(label))
+		}
 	}
-	buttons = append(buttons, cancelView)
 
 	buttonsView := lipgloss.NewStyle().MarginLeft(1).Render(lipgloss.JoinHorizontal(lipgloss.Center, buttons...))
 	availHeight -= lipgloss.Height(buttonsView)
 
+	var statusView string
+	if len(m.testStatus) > 0 {
+		style := blurredStyle
+		if m.testFailed {
+			style = invalidStyle
+		}
+
+		statusView = lipgloss.NewStyle().Padding(0, 0, 0, 2).Render(style.Render(m.testStatus))
+		availHeight -= lipgloss.Height(statusView)
+	}
+
 	inputsView := lipgloss.NewStyle().Height(availHeight).Render(m.inputsView())
 
 	sections = append(sections, inputsView)
 
 	sections = append(sections, buttonsView)
+	if len(statusView) > 0 {
+		sections = append(sections, statusView)
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
@@ -291,6 +555,16 @@ func newBookmarkEditor(config *cfg.Config) *bookmarkEditorModel {
 			key.WithHelp("↑/shift+tab", "prev"),
 		),
 
+		NextChoice: key.NewBinding(
+			key.WithKeys("right"),
+			key.WithHelp("←/→", "change"),
+		),
+
+		PrevChoice: key.NewBinding(
+			key.WithKeys("left"),
+			key.WithHelp("←", "change"),
+		),
+
 		LeaveEditor: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "leave editor"),
@@ -299,43 +573,13 @@ func newBookmarkEditor(config *cfg.Config) *bookmarkEditorModel {
 
 	m := bookmarkEditorModel{
 		config:     config,
-		inputs:     make([]textinput.Model, 4),
+		createNew:  true,
 		focusIndex: 0,
-		inputCount: 5,
 		keys:       keys,
 	}
 
-	var t textinput.Model
-	for i := range m.inputs {
-		t = textinput.NewModel()
-		t.CursorStyle = cursorStyle
-		t.CharLimit = 64
-
-		switch i {
-		case 0:
-			t.Prompt = "Address: "
-			t.Placeholder = "https://files.hostname.tld"
-			t.Focus()
-			t.PromptStyle = focusedStyle
-			t.TextStyle = focusedStyle
-
-		case 1:
-			t.Prompt = "Path: "
-			t.Placeholder = "/home/media"
-
-		case 2:
-			t.Prompt = "Username: "
-			t.Placeholder = "root"
-
-		case 3:
-			t.Prompt = "Password: "
-			t.Placeholder = "toor"
-			t.EchoMode = textinput.EchoPassword
-			t.EchoCharacter = '*'
-		}
-
-		m.inputs[i] = t
-	}
+	m.setFields(m.bookmark)
+	m.updateInputStyles()
 
 	return &m
 }
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+	"github.com/ibrokemypie/kwatch/pkg/source/sourceFile"
+)
+
+func TestDescribeConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{sourceFile.StatusError{URL: "https://host", Status: "401 Unauthorized", StatusCode: 401}, "Authentication failed: "},
+		{sourceFile.StatusError{URL: "https://host", Status: "404 Not Found", StatusCode: 404}, "Server responded "},
+		{sourceFile.ParseError{URL: "https://host", Err: errors.New("no links")}, "Could not read the listing"},
+		{fmt.Errorf("dial: %w", sourceFile.AuthError{Err: errors.New("530 Login incorrect")}), "Authentication failed: "},
+		{errors.New("ssh: unable to authenticate"), "Connection failed: "},
+	}
+
+	for _, test := range tests {
+		got := describeConnectionError(test.err)
+		if !strings.HasPrefix(got, test.want) {
+			t.Errorf("describeConnectionError(%v) = %q, want prefix %q", test.err, got, test.want)
+		}
+	}
+}
+
+func TestChangingBackendClearsHiddenSettings(t *testing.T) {
+	config := &cfg.Config{}
+	config.AddBookmark(bookmark.Bookmark{
+		ID:       "sftp",
+		Backend:  bookmark.SFTP,
+		Address:  "sftp://seedbox.tld",
+		Username: "user",
+		Password: "secret",
+		KeyFile:  "~/.ssh/id_ed25519",
+	})
+
+	model, _ := newBookmarkEditor(config).Update(editBookmarkMsg{bookmarkID: "sftp"})
+	m := model.(*bookmarkEditorModel)
+
+	for i, field := range m.fields {
+		if field.Key == "address" {
+			m.focusIndex = i
+			m.inputs[i].SetValue("https://files.host.tld")
+		}
+	}
+	m.updateBackend()
+
+	b, err := m.formBookmark()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Backend != bookmark.HTTP || len(b.KeyFile) > 0 {
+		t.Errorf("saved as backend %d with key file %q, want HTTP without one", b.Backend, b.KeyFile)
+	}
+	if b.Username != "user" || b.Password != "secret" {
+		t.Errorf("credentials HTTP shows were not kept, got %q:%q", b.Username, b.Password)
+	}
+}
@@ -1,26 +1,91 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
 )
 
+// bookmarkItem shows whether a bookmark is the one opened on start.
+type bookmarkItem struct {
+	bookmark.Bookmark
+	isDefault bool
+}
+
+func (i bookmarkItem) Description() string {
+	description := i.Bookmark.Description()
+	if !i.isDefault {
+		return description
+	}
+
+	if len(description) <= 0 {
+		return "Default"
+	}
+
+	return "Default · " + description
+}
+
+// bookmarkItems lists the config's bookmarks, only those tagged tag when it
+// is set.
+func bookmarkItems(config *cfg.Config, tag string) []list.Item {
+	items := []list.Item{}
+	for _, b := range config.GetBookmarks() {
+		if len(tag) > 0 && !b.HasTag(tag) {
+			continue
+		}
+
+		items = append(items, bookmarkItem{b, b.ID == config.DefaultBookmarkID})
+	}
+
+	return items
+}
+
+// filterTag is the tag a filter starting with "#" asks for.
+func filterTag(filter string) string {
+	if !strings.HasPrefix(filter, "#") {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.Fields(filter)[0], "#")
+}
+
 type bookmarkPickerKeymap struct {
 	NewBookmark    key.Binding
 	EditBookmark   key.Binding
 	SelectBookmark key.Binding
 	ShowFilePicker key.Binding
+	ShowHistory    key.Binding
+	SetDefault     key.Binding
+	Delete         key.Binding
+	Duplicate      key.Binding
+	MoveUp         key.Binding
+	MoveDown       key.Binding
+	Confirm        key.Binding
+	Cancel         key.Binding
 }
 
 type bookmarkPickerModel struct {
 	config *cfg.Config
 	list   list.Model
-	keys   bookmarkPickerKeymap
+	// confirmDelete is set while asking whether to delete the selected
+	// bookmark.
+	confirmDelete bool
+	// tag narrows the list to bookmarks with exactly that tag before the
+	// fuzzy filter sees them, as the list has no way to replace its matching.
+	tag  string
+	keys bookmarkPickerKeymap
 }
 
 func (m bookmarkPickerModel) ShortHelp() []key.Binding {
+	if m.confirmDelete {
+		return []key.Binding{m.keys.Confirm, m.keys.Cancel}
+	}
+
 	bindings := []key.Binding{}
 
 	if len(m.list.Items()) > 0 {
@@ -35,7 +100,8 @@ func (m bookmarkPickerModel) ShortHelp() []key.Binding {
 func (m bookmarkPickerModel) FullHelp() [][]key.Binding {
 	bindings := m.list.FullHelp()
 
-	bindings[1] = append(bindings[1], m.keys.NewBookmark, m.keys.EditBookmark, m.keys.SelectBookmark)
+	bindings[1] = append(bindings[1], m.keys.NewBookmark, m.keys.EditBookmark, m.keys.SelectBookmark, m.keys.ShowHistory, m.keys.SetDefault)
+	bindings = append(bindings, []key.Binding{m.keys.Delete, m.keys.Duplicate, m.keys.MoveUp, m.keys.MoveDown})
 
 	return bindings
 }
@@ -45,6 +111,10 @@ func (m *bookmarkPickerModel) setSize(width, height int) {
 }
 
 func (m bookmarkPickerModel) inputFocused() bool {
+	if m.confirmDelete {
+		return true
+	}
+
 	filterState := m.list.FilterState()
 
 	switch filterState {
@@ -60,32 +130,86 @@ func (m bookmarkPickerModel) Init() tea.Cmd {
 	return nil
 }
 
+func (m *bookmarkPickerModel) updateList() tea.Cmd {
+	return m.list.SetItems(bookmarkItems(m.config, m.tag))
+}
+
+// setTag narrows the list to bookmarks tagged tag, or lists them all again
+// when it is empty.
+func (m *bookmarkPickerModel) setTag(tag string) tea.Cmd {
+	if tag == m.tag {
+		return nil
+	}
+
+	m.tag = tag
+	return m.updateList()
+}
+
+// changeBookmarks applies change to the config, selects the bookmark at
+// selected and saves.
+func (m *bookmarkPickerModel) changeBookmarks(change func(), selected int) tea.Cmd {
+	change()
+
+	cmd := m.updateList()
+	if selected >= len(m.list.Items()) {
+		selected = len(m.list.Items()) - 1
+	}
+	if selected >= 0 {
+		m.list.Select(selected)
+	}
+
+	return tea.Batch(cmd, saveBookmarkCmd)
+}
+
 func (m bookmarkPickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case saveBookmarkMsg:
-		bookmarkList := []list.Item{}
-		for _, bookmark := range m.config.GetBookmarks() {
-			bookmarkList = append(bookmarkList, bookmark)
-		}
-
-		cmds = append(cmds, m.list.SetItems(bookmarkList))
+		cmds = append(cmds, m.updateList())
 
 	case tea.KeyMsg:
+		if m.confirmDelete {
+			index := m.list.Index()
+
+			switch {
+			case key.Matches(msg, m.keys.Confirm):
+				m.confirmDelete = false
+				cmds = append(cmds, m.changeBookmarks(func() {
+					m.config.DeleteBookmark(index)
+				}, index))
+
+			case key.Matches(msg, m.keys.Cancel):
+				m.confirmDelete = false
+			}
+
+			return &m, tea.Batch(cmds...)
+		}
+
 		if m.list.FilterState() == list.Filtering {
+			// Narrow the items for the value being typed before the list
+			// sees the key. The list refilters them whenever the value
+			// changes, while SetItems' own refilter uses the old value and
+			// could land after it, so its command is dropped.
+			filterInput, _ := m.list.FilterInput.Update(msg)
+			m.setTag(filterTag(filterInput.Value()))
 			break
 		}
 
+		// Moving bookmarks needs their places in the whole list.
+		canReorder := m.list.FilterState() == list.Unfiltered
+		index := m.list.Index()
+
 		switch {
 		case key.Matches(msg, m.keys.SelectBookmark):
-			newOpenBookmark := m.list.Index()
-			cmds = append(cmds, updateOpenBookmarkCmd(newOpenBookmark))
+			if selected, ok := m.list.SelectedItem().(bookmarkItem); ok {
+				cmds = append(cmds, updateOpenBookmarkCmd(selected.ID))
+			}
 
 		case key.Matches(msg, m.keys.EditBookmark):
-			if len(m.list.Items()) > 0 {
-				cmds = append(cmds, editBookmarkCmd(m.list.Index()))
+			if selected, ok := m.list.SelectedItem().(bookmarkItem); ok {
+				cmds = append(cmds, editBookmarkCmd(selected.ID))
 			}
 
 		case key.Matches(msg, m.keys.NewBookmark):
@@ -93,27 +217,74 @@ func (m bookmarkPickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.ShowFilePicker):
 			cmds = append(cmds, openBookmarkPickerCmd)
+
+		case key.Matches(msg, m.keys.ShowHistory):
+			cmds = append(cmds, openHistoryCmd)
+
+		case key.Matches(msg, m.keys.SetDefault):
+			if selected, ok := m.list.SelectedItem().(bookmarkItem); ok {
+				id := selected.ID
+				if selected.isDefault {
+					id = ""
+				}
+
+				cmds = append(cmds, m.changeBookmarks(func() {
+					m.config.SetDefaultBookmark(id)
+				}, index))
+			}
+
+		case key.Matches(msg, m.keys.Delete):
+			if len(m.list.Items()) > 0 && canReorder {
+				m.confirmDelete = true
+			}
+
+		case key.Matches(msg, m.keys.Duplicate):
+			if len(m.list.Items()) > 0 && canReorder {
+				cmds = append(cmds, m.changeBookmarks(func() {
+					m.config.DuplicateBookmark(index)
+				}, index+1))
+			}
+
+		case key.Matches(msg, m.keys.MoveUp):
+			if index > 0 && canReorder {
+				cmds = append(cmds, m.changeBookmarks(func() {
+					m.config.SwapBookmarks(index, index-1)
+				}, index-1))
+			}
+
+		case key.Matches(msg, m.keys.MoveDown):
+			if index < len(m.list.Items())-1 && canReorder {
+				cmds = append(cmds, m.changeBookmarks(func() {
+					m.config.SwapBookmarks(index, index+1)
+				}, index+1))
+			}
 		}
 	}
 
 	m.list, cmd = m.list.Update(msg)
 	cmds = append(cmds, cmd)
+
+	if m.list.FilterState() == list.Unfiltered {
+		cmds = append(cmds, m.setTag(""))
+	}
+
 	return &m, tea.Batch(cmds...)
 }
 
 func (m bookmarkPickerModel) View() string {
+	if m.confirmDelete {
+		if selected, ok := m.list.SelectedItem().(list.DefaultItem); ok {
+			m.list.Title = fmt.Sprintf("Delete %s?", selected.Title())
+		}
+	}
+
 	view := m.list.View()
 
 	return view
 }
 
 func newBookmarkPicker(config *cfg.Config) *bookmarkPickerModel {
-	initialList := []list.Item{}
-	for _, bookmark := range config.GetBookmarks() {
-		initialList = append(initialList, bookmark)
-	}
-
-	listModel := list.NewModel(initialList, list.NewDefaultDelegate(), 0, 0)
+	listModel := list.NewModel(bookmarkItems(config, ""), list.NewDefaultDelegate(), 0, 0)
 	listModel.SetShowPagination(false)
 	listModel.SetShowHelp(false)
 	listModel.DisableQuitKeybindings()
@@ -143,6 +314,46 @@ func newBookmarkPicker(config *cfg.Config) *bookmarkPickerModel {
 			key.WithKeys("f"),
 			key.WithHelp("f", "files"),
 		),
+
+		ShowHistory: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "history"),
+		),
+
+		SetDefault: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "set/clear default"),
+		),
+
+		Delete: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "delete"),
+		),
+
+		Duplicate: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "duplicate"),
+		),
+
+		MoveUp: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "move up"),
+		),
+
+		MoveDown: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "move down"),
+		),
+
+		Confirm: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "delete"),
+		),
+
+		Cancel: key.NewBinding(
+			key.WithKeys("n", "esc"),
+			key.WithHelp("n", "keep"),
+		),
 	}
 
 	m := bookmarkPickerModel{
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
+)
+
+func newTestPicker(names ...string) (*bookmarkPickerModel, *cfg.Config) {
+	config := &cfg.Config{}
+	for _, name := range names {
+		config.AddBookmark(bookmark.Bookmark{Name: name, Address: "https://" + name})
+	}
+
+	m := newBookmarkPicker(config)
+	m.setSize(80, 40)
+
+	return m, config
+}
+
+// press sends each key of keys to the picker, "esc" on its own is escape.
+func press(m *bookmarkPickerModel, keys string) *bookmarkPickerModel {
+	if keys == "esc" {
+		model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		return model.(*bookmarkPickerModel)
+	}
+
+	for _, r := range keys {
+		model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = model.(*bookmarkPickerModel)
+	}
+
+	return m
+}
+
+func listedNames(m *bookmarkPickerModel) string {
+	var names []string
+	for _, item := range m.list.Items() {
+		names = append(names, item.(bookmarkItem).Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+func TestTagFilterIsExact(t *testing.T) {
+	m, config := newTestPicker("shows", "cartoons", "films")
+	config.Bookmarks[0].Tags = []string{"anime"}
+	config.Bookmarks[1].Tags = []string{"Animation", "anime"}
+	config.Bookmarks[2].Tags = []string{"animation"}
+	m.updateList()
+
+	m = press(m, "/#anime")
+	if got := listedNames(m); got != "shows cartoons" {
+		t.Errorf("#anime listed %q, want shows cartoons", got)
+	}
+
+	m = press(m, "esc")
+	if got := listedNames(m); got != "shows cartoons films" {
+		t.Errorf("clearing the filter listed %q, want every bookmark", got)
+	}
+
+	m = press(m, "/anim")
+	if got := listedNames(m); got != "shows cartoons films" {
+		t.Errorf("a filter without # narrowed the list to %q", got)
+	}
+}
+
+func TestMoveBookmarks(t *testing.T) {
+	m, config := newTestPicker("a", "b", "c")
+
+	m = press(m, "J")
+	if got := listedNames(m); got != "b a c" || m.list.Index() != 1 {
+		t.Fatalf("moving a down listed %q with %d selected, want b a c with a", got, m.list.Index())
+	}
+
+	m = press(m, "JJ")
+	if got := listedNames(m); got != "b c a" || m.list.Index() != 2 {
+		t.Fatalf("moving a past the end listed %q with %d selected, want b c a with a", got, m.list.Index())
+	}
+
+	m = press(m, "KK")
+	if got := listedNames(m); got != "a b c" || m.list.Index() != 0 {
+		t.Fatalf("moving a up listed %q with %d selected, want a b c with a", got, m.list.Index())
+	}
+
+	if config.Bookmarks[0].Name != "a" || config.Bookmarks[2].Name != "c" {
+		t.Error("the config was not reordered with the list")
+	}
+}
+
+func TestDuplicateBookmark(t *testing.T) {
+	m, config := newTestPicker("a", "b")
+
+	m = press(m, "c")
+	if got := listedNames(m); got != "a a b" || m.list.Index() != 1 {
+		t.Fatalf("duplicating listed %q with %d selected, want a a b with the copy", got, m.list.Index())
+	}
+
+	if config.Bookmarks[1].ID == config.Bookmarks[0].ID || len(config.Bookmarks[1].ID) <= 0 {
+		t.Errorf("the duplicate has ID %q, want a new one", config.Bookmarks[1].ID)
+	}
+}
+
+func TestDeleteBookmark(t *testing.T) {
+	m, config := newTestPicker("a", "b", "c")
+	config.SetDefaultBookmark(config.Bookmarks[1].ID)
+
+	m = press(m, "jD")
+	m = press(m, "n")
+	if got := listedNames(m); got != "a b c" {
+		t.Fatalf("declining the deletion listed %q", got)
+	}
+
+	m = press(m, "Dy")
+	if got := listedNames(m); got != "a c" || m.list.Index() != 1 {
+		t.Fatalf("deleting b listed %q with %d selected, want a c with c", got, m.list.Index())
+	}
+
+	if len(config.DefaultBookmarkID) > 0 {
+		t.Errorf("deleting the default bookmark left default %q", config.DefaultBookmarkID)
+	}
+}
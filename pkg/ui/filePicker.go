@@ -1,31 +1,82 @@
 package ui
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/player"
 	"github.com/ibrokemypie/kwatch/pkg/source"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
 	"github.com/ibrokemypie/kwatch/pkg/source/sourceItem"
 )
 
+// minResumePosition keeps a resume from being offered just after a file was
+// started.
+const minResumePosition = 10 * time.Second
+
+// autoplayDelay is how many seconds there are to cancel autoplay.
+const autoplayDelay = 5
+
 type filePickerKeymap struct {
 	SelectFile         key.Binding
 	GoUp               key.Binding
 	ShowBookmarkPicker key.Binding
+	Resume             key.Binding
+	StartOver          key.Binding
+	CancelResume       key.Binding
+	ToggleWatched      key.Binding
+	NextUnwatched      key.Binding
+	ShowHistory        key.Binding
+	PlayAll            key.Binding
+	CancelAutoplay     key.Binding
 }
 
 type filePickerModel struct {
 	config        *cfg.Config
+	state         *cfg.State
+	stateFilePath string
+	players       *player.Manager
+	// playing maps the IDs of players started here to their files.
+	playing       map[int][]playedFile
+	bookmark      bookmark.Bookmark
 	currentSource source.Source
-	list          list.Model
-	loading       bool
+	// retired sources are closed once no player started here streams from
+	// them.
+	retired []source.Source
+	list    list.Model
+	loading bool
+	// resumeItem is the file waiting on the user to choose whether to resume
+	// from resumePosition.
+	resumeItem     *sourceItem.Item
+	resumePosition time.Duration
+	// selectName is the file to select once the list loads, and to play if
+	// playSelected is set.
+	selectName   string
+	playSelected bool
+	// autoplayItem is played when autoplayIn reaches 0, autoplayToken
+	// changes when it is cancelled.
+	autoplayItem  *sourceItem.Item
+	autoplayIn    int
+	autoplayToken int
 	keys          filePickerKeymap
 }
 
 func (m filePickerModel) ShortHelp() []key.Binding {
+	if m.resumeItem != nil {
+		return []key.Binding{m.keys.Resume, m.keys.StartOver, m.keys.CancelResume}
+	}
+
 	bindings := []key.Binding{}
 
+	if m.autoplayItem != nil {
+		bindings = append(bindings, m.keys.CancelAutoplay)
+	}
+
 	if len(m.list.Items()) > 0 {
 		bindings = append(bindings, m.keys.SelectFile)
 	}
@@ -37,7 +88,7 @@ func (m filePickerModel) ShortHelp() []key.Binding {
 func (m filePickerModel) FullHelp() [][]key.Binding {
 	bindings := m.list.FullHelp()
 
-	bindings[1] = append(bindings[1], m.keys.SelectFile, m.keys.GoUp, m.keys.ShowBookmarkPicker)
+	bindings[1] = append(bindings[1], m.keys.SelectFile, m.keys.GoUp, m.keys.PlayAll, m.keys.ShowBookmarkPicker, m.keys.ShowHistory, m.keys.ToggleWatched, m.keys.NextUnwatched)
 
 	return bindings
 }
@@ -47,6 +98,10 @@ func (m *filePickerModel) setSize(width, height int) {
 }
 
 func (m filePickerModel) inputFocused() bool {
+	if m.resumeItem != nil {
+		return true
+	}
+
 	filterState := m.list.FilterState()
 
 	switch filterState {
@@ -60,7 +115,7 @@ func (m filePickerModel) inputFocused() bool {
 
 func (m filePickerModel) Init() tea.Cmd {
 	if m.config.GetDefaultBookmark() != -1 {
-		return updateOpenBookmarkCmd(m.config.GetDefaultBookmark())
+		return updateOpenBookmarkCmd(m.config.DefaultBookmarkID)
 	}
 
 	return nil
@@ -90,24 +145,327 @@ func (m filePickerModel) initialiseFileList() tea.Cmd {
 	}
 }
 
-func (m filePickerModel) openFile(filePath string) tea.Cmd {
+// playedFile is a file handed to a player, url is how the player reports it
+// over IPC.
+type playedFile struct {
+	url  string
+	file cfg.FileState
+}
+
+// fileState is the stored state of filePath in the current directory.
+func (m filePickerModel) fileState(filePath string) cfg.FileState {
+	return m.storedFile(cfg.NewFileState(m.currentSource.GetAddressString(), m.currentSource.GetPathString(), filePath))
+}
+
+func (m filePickerModel) storedFile(file cfg.FileState) cfg.FileState {
+	stored, ok := m.state.GetFile(file.Key())
+	if !ok {
+		return file
+	}
+
+	// State saved before files were listed in the history has no location.
+	stored.Address, stored.Dir, stored.Name = file.Address, file.Dir, file.Name
+	return stored
+}
+
+// resumePositionOf is where playback of filePath stopped last time, or 0 when
+// it should start from the beginning.
+func (m filePickerModel) resumePositionOf(filePath string) time.Duration {
+	file := m.fileState(filePath)
+	if file.Watched || file.Position < minResumePosition {
+		return 0
+	}
+
+	return file.Position
+}
+
+// setSource replaces the current source, closing the old one once players
+// are done with it.
+func (m *filePickerModel) setSource(s source.Source) {
+	if m.currentSource != nil {
+		m.retired = append(m.retired, m.currentSource)
+	}
+
+	m.currentSource = s
+	m.closeRetired()
+}
+
+func (m *filePickerModel) closeRetired() {
+	if len(m.playing) > 0 {
+		return
+	}
+
+	for _, s := range m.retired {
+		s.Close()
+	}
+	m.retired = nil
+}
+
+// savePosition remembers when files were played and where the player
+// stopped, files played past the watched threshold are marked watched and
+// started over next time.
+func (m filePickerModel) savePosition(process *player.Process) tea.Cmd {
+	played, ok := m.playing[process.ID]
+	if !ok {
+		return nil
+	}
+	delete(m.playing, process.ID)
+
+	// Without IPC only when the first file was played is known. With it,
+	// playlist entries before the one the player stopped on were played
+	// through.
+	playback, hasPlayback := process.State()
+
+	current := 0
+	if hasPlayback {
+		for i, entry := range played {
+			if cfg.RedactAddress(entry.url) == cfg.RedactAddress(playback.Path) {
+				current = i
+			}
+		}
+	}
+
+	now := time.Now()
+	for i, entry := range played[:current+1] {
+		file := m.storedFile(entry.file)
+		file.LastPlayed = now.Add(time.Duration(i-current) * time.Second)
+
+		switch {
+		case i < current:
+			file.Watched = true
+			file.Position = 0
+
+		case hasPlayback:
+			file.Position = playback.Position
+			if playback.Duration > 0 {
+				file.Duration = playback.Duration
+			}
+
+			if playback.Progress() >= m.config.GetWatchedThreshold() {
+				file.Watched = true
+				file.Position = 0
+			}
+		}
+
+		m.state.UpdateFile(file)
+	}
+
+	return m.writeState()
+}
+
+func (m filePickerModel) writeState() tea.Cmd {
+	err := m.state.WriteState(m.stateFilePath)
+	if err != nil {
+		return errorCmd(err)
+	}
+
+	return nil
+}
+
+// markItems sets the watched state of the files in listItems.
+func (m filePickerModel) markItems(listItems []list.Item) []list.Item {
+	marked := make([]list.Item, len(listItems))
+
+	for i, listItem := range listItems {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.ListingType == "file" {
+			file := m.fileState(item.Path)
+			item.Watched = file.Watched
+			item.Progress = file.Progress()
+			listItem = item
+		}
+
+		marked[i] = listItem
+	}
+
+	return marked
+}
+
+func (m *filePickerModel) toggleWatched(item sourceItem.Item) tea.Cmd {
+	file := m.fileState(item.Path)
+	file.Watched = !file.Watched
+	file.Position = 0
+	m.state.UpdateFile(file)
+
+	return tea.Batch(m.list.SetItems(m.markItems(m.list.Items())), m.writeState())
+}
+
+// selectFile moves the cursor to the file named name, and plays it from where
+// it was stopped when play is set.
+func (m *filePickerModel) selectFile(name string, play bool) tea.Cmd {
+	for index, listItem := range m.list.VisibleItems() {
+		item, ok := listItem.(sourceItem.Item)
+		if !ok || item.Path != name {
+			continue
+		}
+
+		m.list.Select(index)
+
+		if play && item.ListingType == "file" {
+			m.loading = true
+			return tea.Batch(m.list.StartSpinner(), m.openFile(item.Path, m.resumePositionOf(item.Path)))
+		}
+
+		return nil
+	}
+
+	return errorCmd(fmt.Errorf("%s: not found", name))
+}
+
+// nextFile is the media file after name in the current directory, in
+// natural order.
+func (m filePickerModel) nextFile(name string) (sourceItem.Item, bool) {
+	var files []sourceItem.Item
+	for _, listItem := range m.list.Items() {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.ListingType == "file" && player.IsMedia(item.Name) {
+			files = append(files, item)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return sourceItem.NaturalLess(files[i].Name, files[j].Name)
+	})
+
+	for i, file := range files {
+		if file.Path == name && i+1 < len(files) {
+			return files[i+1], true
+		}
+	}
+
+	return sourceItem.Item{}, false
+}
+
+// startAutoplay counts down to playing the file after the one a player
+// finished, if the bookmark autoplays and the directory is still open.
+func (m *filePickerModel) startAutoplay(msg playerExitMsg) tea.Cmd {
+	played, ok := m.playing[msg.process.ID]
+	if !ok || !m.bookmark.Autoplay || msg.err != nil || msg.process.Killed() {
+		return nil
+	}
+
+	// Quitting a player with IPC part way through is not finishing it.
+	if playback, ok := msg.process.State(); ok && playback.Progress() < m.config.GetWatchedThreshold() {
+		return nil
+	}
+
+	last := played[len(played)-1].file
+	current := cfg.NewFileState(m.currentSource.GetAddressString(), m.currentSource.GetPathString(), last.Name)
+	if current.Key() != last.Key() {
+		return nil
+	}
+
+	next, ok := m.nextFile(last.Name)
+	if !ok {
+		return nil
+	}
+
+	m.autoplayItem = &next
+	m.autoplayIn = autoplayDelay
+	m.autoplayToken++
+
+	return tea.Batch(m.autoplayStatus(), autoplayTickCmd(m.autoplayToken))
+}
+
+func (m *filePickerModel) cancelAutoplay() {
+	m.autoplayItem = nil
+	m.autoplayToken++
+}
+
+func (m *filePickerModel) autoplayStatus() tea.Cmd {
+	return m.list.NewStatusMessage(fmt.Sprintf("Playing %s in %ds, esc to cancel", m.autoplayItem.Name, m.autoplayIn))
+}
+
+// selectNextUnwatched moves the cursor to the first unwatched file after it,
+// wrapping around to the top.
+func (m *filePickerModel) selectNextUnwatched() {
+	visibleItems := m.list.VisibleItems()
+
+	for offset := 1; offset <= len(visibleItems); offset++ {
+		index := (m.list.Index() + offset) % len(visibleItems)
+
+		item, ok := visibleItems[index].(sourceItem.Item)
+		if ok && item.ListingType == "file" && !item.Watched {
+			m.list.Select(index)
+			return
+		}
+	}
+}
+
+func (m filePickerModel) openFile(filePath string, start time.Duration) tea.Cmd {
+	return m.openFiles([]string{filePath}, start)
+}
+
+// openFiles plays filePaths from the current directory, as a playlist when
+// there is more than one.
+func (m filePickerModel) openFiles(filePaths []string, start time.Duration) tea.Cmd {
+	address, dir := m.currentSource.GetAddressString(), m.currentSource.GetPathString()
+
 	return func() tea.Msg {
-		err := m.currentSource.OpenFile(filePath)
+		var entries []*player.Media
+
+		for _, filePath := range filePaths {
+			media, err := m.currentSource.OpenFile(filePath)
+			if err != nil {
+				for _, entry := range entries {
+					entry.Close()
+				}
+				return errorMsg{err}
+			}
+
+			entries = append(entries, media)
+		}
+
+		media := entries[0]
+		if len(entries) > 1 {
+			media = player.NewPlaylist(entries)
+		}
+		media.Start = start
+		media.Args = m.bookmark.PlayerArgs
+
+		// The player runs in the background, the media is closed by the
+		// manager once it exits.
+		process, err := m.players.Start(m.config.GetPlayer(m.bookmark.FileViewer), media)
 		if err != nil {
 			return errorMsg{err}
 		}
 
-		return endFileOpenMsg{}
+		// Starting the player can move the media behind a proxy, so the URLs
+		// it reports are only known now.
+		var played []playedFile
+		for i, entry := range entries {
+			played = append(played, playedFile{entry.URL, cfg.NewFileState(address, dir, filePaths[i])})
+		}
+
+		return endFileOpenMsg{process, played}
 	}
 }
 
+// playAllFrom plays the media files from the selected one to the end of the
+// list, in the order they are shown.
+func (m filePickerModel) playAllFrom() tea.Cmd {
+	var filePaths []string
+	for _, listItem := range m.list.VisibleItems()[m.list.Index():] {
+		item, ok := listItem.(sourceItem.Item)
+		if ok && item.ListingType == "file" && player.IsMedia(item.Name) {
+			filePaths = append(filePaths, item.Path)
+		}
+	}
+
+	if len(filePaths) <= 0 {
+		return nil
+	}
+
+	return m.openFiles(filePaths, 0)
+}
+
 func (m filePickerModel) pickItem(i sourceItem.Item) tea.Cmd {
 	switch i.ListingType {
-	case "dir":
+	case "dir", "archive":
 		return m.changeDir(i.Path)
 
 	case "file":
-		return m.openFile(i.Path)
+		return m.openFile(i.Path, 0)
 	}
 
 	return nil
@@ -123,32 +481,115 @@ func (m filePickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 		m.loading = false
 
 	case updateOpenBookmarkMsg:
+		index := m.config.FindBookmarkID(msg.bookmarkID)
+		if index < 0 {
+			return &m, openBookmarkPickerCmd
+		}
+
 		m.loading = true
 		m.list.SetItems([]list.Item{})
 
-		bookmark := m.config.GetBookmark(msg.newOpenBookmark)
-		m.currentSource = source.NewSource(bookmark)
+		m.bookmark = m.config.GetBookmark(index)
+		m.setSource(source.NewSource(m.bookmark))
 
-		pathString := bookmark.Path
-		m.list.Title = bookmark.Address + pathString
+		pathString := m.bookmark.Path
+		m.list.Title = m.bookmark.Address + pathString
+
+		cmds = append(cmds, m.list.StartSpinner(), m.initialiseFileList())
+
+	case openHistoryFileMsg:
+		m.loading = true
+		m.list.SetItems([]list.Item{})
+
+		m.bookmark = msg.bookmark
+		m.setSource(source.NewSourceAt(m.bookmark, msg.file.Dir))
+		m.selectName = msg.file.Name
+		m.playSelected = msg.play
+
+		m.list.Title = m.bookmark.Address + "/" + msg.file.Dir
 
 		cmds = append(cmds, m.list.StartSpinner(), m.initialiseFileList())
 
 	case endListUpdateMsg:
+		m.cancelAutoplay()
 		m.list.StopSpinner()
 		m.list.ResetFilter()
 		m.list.ResetSelected()
 		m.loading = false
 		m.list.Title = m.currentSource.GetAddressString() + "/" + m.currentSource.GetPathString()
 
-		cmds = append(cmds, clearErrorCmd, m.list.SetItems(msg.itemList))
+		cmds = append(cmds, clearErrorCmd, m.list.SetItems(m.markItems(msg.itemList)))
+
+		if len(m.selectName) > 0 {
+			cmds = append(cmds, m.selectFile(m.selectName, m.playSelected))
+			m.selectName = ""
+		}
 
 	case endFileOpenMsg:
+		m.cancelAutoplay()
 		m.list.StopSpinner()
 		m.loading = false
+		m.playing[msg.process.ID] = msg.played
 		cmds = append(cmds, clearErrorCmd)
 
+	case playerExitMsg:
+		if m.currentSource != nil {
+			cmds = append(cmds, m.startAutoplay(msg))
+		}
+		cmds = append(cmds, m.savePosition(msg.process))
+		m.closeRetired()
+		if m.currentSource != nil {
+			cmds = append(cmds, m.list.SetItems(m.markItems(m.list.Items())))
+		}
+
+	case autoplayTickMsg:
+		if m.autoplayItem == nil || msg.token != m.autoplayToken {
+			break
+		}
+
+		m.autoplayIn--
+		if m.autoplayIn > 0 {
+			cmds = append(cmds, m.autoplayStatus(), autoplayTickCmd(m.autoplayToken))
+			break
+		}
+
+		if !m.loading {
+			m.loading = true
+			cmds = append(cmds, m.list.StartSpinner(), m.openFile(m.autoplayItem.Path, m.resumePositionOf(m.autoplayItem.Path)))
+		}
+		m.cancelAutoplay()
+
 	case tea.KeyMsg:
+		if m.autoplayItem != nil && key.Matches(msg, m.keys.CancelAutoplay) {
+			m.cancelAutoplay()
+			cmds = append(cmds, m.list.NewStatusMessage("Autoplay cancelled"))
+			return &m, tea.Batch(cmds...)
+		}
+
+		if m.resumeItem != nil {
+			start := time.Duration(0)
+
+			switch {
+			case key.Matches(msg, m.keys.Resume):
+				start = m.resumePosition
+
+			case key.Matches(msg, m.keys.StartOver):
+
+			case key.Matches(msg, m.keys.CancelResume):
+				m.resumeItem = nil
+				return &m, nil
+
+			default:
+				return &m, nil
+			}
+
+			m.loading = true
+			cmds = append(cmds, m.list.StartSpinner(), m.openFile(m.resumeItem.Path, start))
+			m.resumeItem = nil
+
+			return &m, tea.Batch(cmds...)
+		}
+
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
@@ -160,6 +601,14 @@ func (m filePickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 		switch {
 		case key.Matches(msg, m.keys.SelectFile):
 			i, ok := m.list.SelectedItem().(sourceItem.Item)
+			if ok && i.ListingType == "file" {
+				if position := m.resumePositionOf(i.Path); position > 0 {
+					m.resumeItem = &i
+					m.resumePosition = position
+					break
+				}
+			}
+
 			if ok {
 				m.loading = true
 				cmds = append(cmds, m.list.StartSpinner(), m.pickItem(i))
@@ -176,6 +625,24 @@ func (m filePickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.ShowBookmarkPicker):
 			cmds = append(cmds, openBookmarkPickerCmd)
+
+		case key.Matches(msg, m.keys.ToggleWatched):
+			i, ok := m.list.SelectedItem().(sourceItem.Item)
+			if ok && i.ListingType == "file" {
+				cmds = append(cmds, m.toggleWatched(i))
+			}
+
+		case key.Matches(msg, m.keys.NextUnwatched):
+			m.selectNextUnwatched()
+
+		case key.Matches(msg, m.keys.ShowHistory):
+			cmds = append(cmds, openHistoryCmd)
+
+		case key.Matches(msg, m.keys.PlayAll):
+			if cmd := m.playAllFrom(); cmd != nil {
+				m.loading = true
+				cmds = append(cmds, m.list.StartSpinner(), cmd)
+			}
 		}
 
 	case tea.MouseMsg:
@@ -199,16 +666,22 @@ func (m filePickerModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
 }
 
 func (m filePickerModel) View() string {
+	if m.resumeItem != nil {
+		m.list.Title = fmt.Sprintf("Resume %s from %s?", m.resumeItem.Name, formatPosition(m.resumePosition))
+	}
+
 	view := m.list.View()
 
 	return view
 }
 
-func newFilePicker(config *cfg.Config) *filePickerModel {
+func newFilePicker(config *cfg.Config, state *cfg.State, stateFilePath string, players *player.Manager) *filePickerModel {
 	listModel := list.NewModel([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	listModel.SetShowPagination(false)
 	listModel.SetShowHelp(false)
 	listModel.DisableQuitKeybindings()
+	// Long enough for each step of the autoplay countdown to replace the last.
+	listModel.StatusMessageLifetime = 2 * time.Second
 
 	listModel.KeyMap.ShowFullHelp.SetEnabled(false)
 	listModel.KeyMap.CloseFullHelp.SetEnabled(false)
@@ -226,13 +699,49 @@ func newFilePicker(config *cfg.Config) *filePickerModel {
 			key.WithKeys("b"),
 			key.WithHelp("b", "bookmarks"),
 		),
+		Resume: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "resume"),
+		),
+		StartOver: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "start over"),
+		),
+		CancelResume: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		ToggleWatched: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle watched"),
+		),
+		NextUnwatched: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "next unwatched"),
+		),
+		ShowHistory: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "history"),
+		),
+		PlayAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "play all from here"),
+		),
+		CancelAutoplay: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel autoplay"),
+		),
 	}
 
 	m := filePickerModel{
-		config:  config,
-		list:    listModel,
-		loading: false,
-		keys:    keys,
+		config:        config,
+		state:         state,
+		stateFilePath: stateFilePath,
+		players:       players,
+		playing:       map[int][]playedFile{},
+		list:          listModel,
+		loading:       false,
+		keys:          keys,
 	}
 
 	return &m
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ibrokemypie/kwatch/pkg/cfg"
+)
+
+type historyItem struct {
+	cfg.FileState
+}
+
+func (i historyItem) Title() string {
+	return i.Name
+}
+
+func (i historyItem) Description() string {
+	description := i.LastPlayed.Local().Format("2006-01-02 15:04")
+
+	if i.Watched {
+		description += "  WATCHED"
+	} else if i.Position > 0 && i.Duration > 0 {
+		description += "  " + formatPosition(i.Position) + " / " + formatPosition(i.Duration)
+	}
+
+	return description + "  " + i.Address + path.Join("/", i.Dir)
+}
+
+func (i historyItem) FilterValue() string {
+	return i.Address + path.Join("/", i.Dir, i.Name)
+}
+
+type historyKeymap struct {
+	OpenFile       key.Binding
+	OpenDir        key.Binding
+	ShowFilePicker key.Binding
+}
+
+type historyModel struct {
+	config *cfg.Config
+	state  *cfg.State
+	list   list.Model
+	keys   historyKeymap
+}
+
+func (m historyModel) ShortHelp() []key.Binding {
+	bindings := []key.Binding{}
+
+	if len(m.list.Items()) > 0 {
+		bindings = append(bindings, m.keys.OpenFile, m.keys.OpenDir)
+	}
+	bindings = append(bindings, m.keys.ShowFilePicker)
+	bindings = append(bindings, m.list.ShortHelp()...)
+
+	return bindings
+}
+
+func (m historyModel) FullHelp() [][]key.Binding {
+	bindings := m.list.FullHelp()
+
+	bindings[1] = append(bindings[1], m.keys.OpenFile, m.keys.OpenDir, m.keys.ShowFilePicker)
+
+	return bindings
+}
+
+func (m *historyModel) setSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+func (m historyModel) inputFocused() bool {
+	filterState := m.list.FilterState()
+
+	switch filterState {
+	case list.Filtering:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (m historyModel) Init() tea.Cmd {
+	return nil
+}
+
+// openSelected opens the selected file's directory with the bookmark for its
+// address.
+func (m historyModel) openSelected(play bool) tea.Cmd {
+	i, ok := m.list.SelectedItem().(historyItem)
+	if !ok {
+		return nil
+	}
+
+	b, ok := m.config.FindBookmark(i.Address)
+	if !ok {
+		return errorCmd(fmt.Errorf("%s: no bookmark for this address", i.Address))
+	}
+
+	return openHistoryFileCmd(b, i.FileState, play)
+}
+
+func (m historyModel) Update(msg tea.Msg) (childModel, tea.Cmd) {
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case openHistoryMsg:
+		historyList := []list.Item{}
+		for _, file := range m.state.History() {
+			historyList = append(historyList, historyItem{file})
+		}
+
+		m.list.ResetFilter()
+		m.list.ResetSelected()
+		cmds = append(cmds, m.list.SetItems(historyList))
+
+	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.OpenFile):
+			cmds = append(cmds, m.openSelected(true))
+
+		case key.Matches(msg, m.keys.OpenDir):
+			cmds = append(cmds, m.openSelected(false))
+
+		case key.Matches(msg, m.keys.ShowFilePicker):
+			cmds = append(cmds, openFilePickerCmd)
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+	return &m, tea.Batch(cmds...)
+}
+
+func (m historyModel) View() string {
+	view := m.list.View()
+
+	return view
+}
+
+func newHistory(config *cfg.Config, state *cfg.State) *historyModel {
+	listModel := list.NewModel([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	listModel.SetShowPagination(false)
+	listModel.SetShowHelp(false)
+	listModel.DisableQuitKeybindings()
+
+	listModel.KeyMap.ShowFullHelp.SetEnabled(false)
+	listModel.KeyMap.CloseFullHelp.SetEnabled(false)
+
+	listModel.Title = "Recently played."
+
+	keys := historyKeymap{
+		OpenFile: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "play"),
+		),
+
+		OpenDir: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open directory"),
+		),
+
+		ShowFilePicker: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "files"),
+		),
+	}
+
+	m := historyModel{
+		config: config,
+		state:  state,
+		list:   listModel,
+		keys:   keys,
+	}
+
+	return &m
+}
@@ -1,8 +1,13 @@
 package ui
 
 import (
+	"time"
+
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/player"
+	"github.com/ibrokemypie/kwatch/pkg/source/bookmark"
 )
 
 type errorMsg struct {
@@ -24,25 +29,32 @@ func clearErrorCmd() tea.Msg {
 }
 
 type updateOpenBookmarkMsg struct {
-	newOpenBookmark int
+	bookmarkID string
 }
 
-func updateOpenBookmarkCmd(newOpenBookmark int) tea.Cmd {
+func updateOpenBookmarkCmd(bookmarkID string) tea.Cmd {
 	return func() tea.Msg {
-		return updateOpenBookmarkMsg{newOpenBookmark}
+		return updateOpenBookmarkMsg{bookmarkID}
 	}
 }
 
 type editBookmarkMsg struct {
-	bookmarkIndex int
+	bookmarkID string
 }
 
-func editBookmarkCmd(index int) tea.Cmd {
+func editBookmarkCmd(bookmarkID string) tea.Cmd {
 	return func() tea.Msg {
-		return editBookmarkMsg{bookmarkIndex: index}
+		return editBookmarkMsg{bookmarkID: bookmarkID}
 	}
 }
 
+// connectionTestMsg is the result of listing a bookmark from the editor.
+type connectionTestMsg struct {
+	token int
+	items int
+	err   error
+}
+
 type newBookmarkMsg struct{}
 
 func newBookmarkCmd() tea.Msg {
@@ -59,10 +71,71 @@ type endListUpdateMsg struct {
 	itemList []list.Item
 }
 
-type endFileOpenMsg struct{}
+type endFileOpenMsg struct {
+	process *player.Process
+	played  []playedFile
+}
+
+type playerExitMsg struct {
+	process *player.Process
+	err     error
+}
+
+func waitPlayerCmd(process *player.Process) tea.Cmd {
+	return func() tea.Msg {
+		return playerExitMsg{process, process.Wait()}
+	}
+}
+
+type openFilePickerMsg struct{}
+
+func openFilePickerCmd() tea.Msg {
+	return openFilePickerMsg{}
+}
+
+type openHistoryMsg struct{}
+
+func openHistoryCmd() tea.Msg {
+	return openHistoryMsg{}
+}
+
+// openHistoryFileMsg opens the directory of a file from the history in the
+// file picker, selecting the file and playing it when play is set.
+type openHistoryFileMsg struct {
+	bookmark bookmark.Bookmark
+	file     cfg.FileState
+	play     bool
+}
+
+func openHistoryFileCmd(b bookmark.Bookmark, file cfg.FileState, play bool) tea.Cmd {
+	return func() tea.Msg {
+		return openHistoryFileMsg{b, file, play}
+	}
+}
 
 type openBookmarkPickerMsg struct{}
 
 func openBookmarkPickerCmd() tea.Msg {
 	return openBookmarkPickerMsg{}
 }
+
+// playerTickMsg refreshes the playback position of running players.
+type playerTickMsg struct{}
+
+func playerTickCmd() tea.Cmd {
+	return tea.Tick(time.Second/2, func(time.Time) tea.Msg {
+		return playerTickMsg{}
+	})
+}
+
+// autoplayTickMsg counts down to the next file being played, token tells
+// ticks of a cancelled countdown apart.
+type autoplayTickMsg struct {
+	token int
+}
+
+func autoplayTickCmd(token int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return autoplayTickMsg{token}
+	})
+}
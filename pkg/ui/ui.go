@@ -2,21 +2,28 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/ibrokemypie/kwatch/pkg/cfg"
+	"github.com/ibrokemypie/kwatch/pkg/player"
 )
 
+// seekStep is how far the seek keys move playback.
+const seekStep = 10 * time.Second
+
 type childView int
 
 const (
 	filePicker childView = iota
 	bookmarkPicker
 	bookmarkEditor
+	history
 )
 
 type childModel interface {
@@ -34,6 +41,12 @@ type mainKeyMap struct {
 	HideFullHelp key.Binding
 	Quit         key.Binding
 	ForceQuit    key.Binding
+	NextPlayer   key.Binding
+	KillPlayer   key.Binding
+	TogglePause  key.Binding
+	SeekBack     key.Binding
+	SeekForward  key.Binding
+	NextFile     key.Binding
 }
 
 type mainModel struct {
@@ -41,11 +54,18 @@ type mainModel struct {
 	confFilePath string
 	currentChild childView
 	childModels  []childModel
-	helpModel    help.Model
-	keys         mainKeyMap
-	width        int
-	height       int
-	err          error
+	players      *player.Manager
+	// focusedPlayer is the ID of the player shown in the status line and
+	// acted on by the player keys.
+	focusedPlayer int
+	// ticking is set while playerTickMsg is refreshing the status line.
+	ticking   bool
+	progress  progress.Model
+	helpModel help.Model
+	keys      mainKeyMap
+	width     int
+	height    int
+	err       error
 }
 
 func (m mainModel) ShortHelp() []key.Binding {
@@ -68,9 +88,98 @@ func (m mainModel) FullHelp() [][]key.Binding {
 
 	bindings[len(bindings)-2] = append(bindings[len(bindings)-2], m.keys.HideFullHelp)
 
+	if len(m.players.Processes()) > 0 {
+		bindings = append(bindings, []key.Binding{m.keys.NextPlayer, m.keys.KillPlayer})
+		bindings = append(bindings, []key.Binding{m.keys.TogglePause, m.keys.SeekBack, m.keys.SeekForward, m.keys.NextFile})
+	}
+
 	return bindings
 }
 
+func (m mainModel) focusedProcess() *player.Process {
+	return m.players.Get(m.focusedPlayer)
+}
+
+// focusNextPlayer moves focus to the player started after the focused one,
+// wrapping around to the oldest.
+func (m *mainModel) focusNextPlayer() {
+	processes := m.players.Processes()
+	if len(processes) <= 0 {
+		m.focusedPlayer = 0
+		return
+	}
+
+	for i, process := range processes {
+		if process.ID == m.focusedPlayer {
+			m.focusedPlayer = processes[(i+1)%len(processes)].ID
+			return
+		}
+	}
+
+	m.focusedPlayer = processes[len(processes)-1].ID
+}
+
+func (m mainModel) statusView() string {
+	processes := m.players.Processes()
+	process := m.focusedProcess()
+	if process == nil {
+		return ""
+	}
+
+	status := "Now playing: " + process.Title
+	if len(processes) > 1 {
+		for i, p := range processes {
+			if p == process {
+				status += fmt.Sprintf(" (%d/%d)", i+1, len(processes))
+			}
+		}
+	}
+
+	status = "\n" + list.DefaultStyles().StatusBar.Render(status)
+
+	state, ok := process.State()
+	if ok {
+		position := formatPosition(state.Position) + " / " + formatPosition(state.Duration)
+		if state.Paused {
+			position += " (paused)"
+		}
+
+		m.progress.Width = m.width - len(position) - 4
+		if m.progress.Width < 10 {
+			m.progress.Width = 10
+		}
+		status += "\n" + list.DefaultStyles().StatusBar.Render(m.progress.ViewAs(state.Progress())+" "+position)
+	}
+
+	return status
+}
+
+// formatPosition formats a playback position like a player would, as
+// h:mm:ss or m:ss.
+func formatPosition(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// playerCommand runs command on the focused player.
+func (m mainModel) playerCommand(command func(*player.Process) error) tea.Cmd {
+	process := m.focusedProcess()
+	if process == nil {
+		return nil
+	}
+
+	err := command(process)
+	if err != nil {
+		return errorCmd(err)
+	}
+
+	return nil
+}
+
 func (m mainModel) helpView() string {
 	return list.DefaultStyles().HelpStyle.Render(m.helpModel.View(m))
 }
@@ -90,6 +199,9 @@ func (m *mainModel) updateContents() {
 	availHeight := m.height
 	availHeight -= lipgloss.Height(m.helpView())
 	availHeight--
+	if status := m.statusView(); len(status) > 0 {
+		availHeight -= lipgloss.Height(status) - 1
+	}
 
 	m.childModels[m.currentChild].setSize(width, availHeight)
 }
@@ -122,6 +234,16 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateContents()
 		cmds = append(cmds, clearErrorCmd)
 
+	case openHistoryMsg:
+		m.currentChild = history
+		m.updateContents()
+		cmds = append(cmds, clearErrorCmd)
+
+	case openFilePickerMsg, openHistoryFileMsg:
+		m.currentChild = filePicker
+		m.updateContents()
+		cmds = append(cmds, clearErrorCmd)
+
 	case saveBookmarkMsg:
 		err := m.config.WriteConfig(m.confFilePath)
 		if err != nil {
@@ -137,6 +259,35 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateContents()
 		cmds = append(cmds, clearErrorCmd)
 
+	case endFileOpenMsg:
+		m.focusedPlayer = msg.process.ID
+		m.updateContents()
+		cmds = append(cmds, waitPlayerCmd(msg.process))
+
+		if !m.ticking {
+			m.ticking = true
+			cmds = append(cmds, playerTickCmd())
+		}
+
+	case playerTickMsg:
+		// The status line height changes once a player's IPC connects.
+		m.updateContents()
+
+		m.ticking = len(m.players.Processes()) > 0
+		if m.ticking {
+			cmds = append(cmds, playerTickCmd())
+		}
+
+	case playerExitMsg:
+		if msg.process.ID == m.focusedPlayer {
+			m.focusNextPlayer()
+		}
+		m.updateContents()
+
+		if msg.err != nil {
+			cmds = append(cmds, errorCmd(fmt.Errorf("%s: %s", msg.process.Title, msg.err)))
+		}
+
 	case tea.KeyMsg:
 		if key.Matches(msg, m.keys.ForceQuit) {
 			return m, tea.Quit
@@ -150,10 +301,42 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			case key.Matches(msg, m.keys.Quit):
 				return m, tea.Quit
+
+			case key.Matches(msg, m.keys.NextPlayer):
+				m.focusNextPlayer()
+
+			case key.Matches(msg, m.keys.KillPlayer):
+				cmds = append(cmds, m.playerCommand((*player.Process).Kill))
+
+			case key.Matches(msg, m.keys.TogglePause):
+				cmds = append(cmds, m.playerCommand((*player.Process).TogglePause))
+
+			case key.Matches(msg, m.keys.SeekBack):
+				cmds = append(cmds, m.playerCommand(func(p *player.Process) error {
+					return p.Seek(-seekStep)
+				}))
+
+			case key.Matches(msg, m.keys.SeekForward):
+				cmds = append(cmds, m.playerCommand(func(p *player.Process) error {
+					return p.Seek(seekStep)
+				}))
+
+			case key.Matches(msg, m.keys.NextFile):
+				cmds = append(cmds, m.playerCommand((*player.Process).Next))
 			}
 		}
 	}
 
+	// The file picker waits on players it started, and counts down to the
+	// next file, even while another view is open.
+	switch msg.(type) {
+	case endFileOpenMsg, playerExitMsg, autoplayTickMsg:
+		if m.currentChild != filePicker {
+			m.childModels[filePicker], cmd = m.childModels[filePicker].Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
 	m.childModels[m.currentChild], cmd = m.childModels[m.currentChild].Update(msg)
 
 	cmds = append(cmds, cmd)
@@ -164,6 +347,7 @@ func (m mainModel) View() string {
 	var view string
 
 	view += m.childModels[m.currentChild].View()
+	view += m.statusView()
 	view += m.helpView()
 
 	if m.err != nil {
@@ -173,7 +357,7 @@ func (m mainModel) View() string {
 	return view
 }
 
-func NewProgram(config *cfg.Config, confFilePath string) *tea.Program {
+func NewProgram(config *cfg.Config, confFilePath string, state *cfg.State, stateFilePath string) *tea.Program {
 	keys := mainKeyMap{
 		ShowFullHelp: key.NewBinding(
 			key.WithKeys("?"),
@@ -191,12 +375,45 @@ func NewProgram(config *cfg.Config, confFilePath string) *tea.Program {
 		),
 
 		ForceQuit: key.NewBinding(key.WithKeys("ctrl+c")),
+
+		NextPlayer: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "next player"),
+		),
+
+		KillPlayer: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "stop player"),
+		),
+
+		TogglePause: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "pause"),
+		),
+
+		SeekBack: key.NewBinding(
+			key.WithKeys(","),
+			key.WithHelp(",", "back 10s"),
+		),
+
+		SeekForward: key.NewBinding(
+			key.WithKeys("."),
+			key.WithHelp(".", "forward 10s"),
+		),
+
+		NextFile: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "next file"),
+		),
 	}
 
+	players := player.NewManager()
+
 	childModels := []childModel{
-		newFilePicker(config),
+		newFilePicker(config, state, stateFilePath, players),
 		newBookmarkPicker(config),
 		newBookmarkEditor(config),
+		newHistory(config, state),
 	}
 	currentChild := bookmarkPicker
 	if config.GetDefaultBookmark() != -1 {
@@ -208,6 +425,8 @@ func NewProgram(config *cfg.Config, confFilePath string) *tea.Program {
 		confFilePath: confFilePath,
 		currentChild: currentChild,
 		childModels:  childModels,
+		players:      players,
+		progress:     progress.NewModel(progress.WithDefaultGradient(), progress.WithoutPercentage()),
 		helpModel:    help.NewModel(),
 		keys:         keys,
 		err:          nil,